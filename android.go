@@ -0,0 +1,135 @@
+//
+// osinfo/android.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// buildPropFiles is the list of property files in the order Android loads them.
+var buildPropFiles = []string{
+	"system/build.prop",
+	"system/system/build.prop",
+	"vendor/build.prop",
+	"product/etc/build.prop",
+}
+
+// Android is the Android build information.
+type Android struct {
+	Release       string   `json:"release,omitempty" yaml:"release,omitempty"`
+	SDK           string   `json:"sdk,omitempty" yaml:"sdk,omitempty"`
+	SecurityPatch string   `json:"security_patch,omitempty" yaml:"security_patch,omitempty"`
+	Brand         string   `json:"brand,omitempty" yaml:"brand,omitempty"`
+	Model         string   `json:"model,omitempty" yaml:"model,omitempty"`
+	Device        string   `json:"device,omitempty" yaml:"device,omitempty"`
+	ABIs          []string `json:"abis,omitempty" yaml:"abis,omitempty"`
+	Fingerprint   string   `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+}
+
+// GetAndroid returns the Android build information of this system, or nil
+// when not running on Android.
+func GetAndroid() *Android {
+	if !isAndroid() {
+		return nil
+	}
+	return localAndroid()
+}
+
+// localAndroid returns the build information of this system. During a
+// collection the build.prop files are parsed once and shared by the distro,
+// model and android sections.
+func localAndroid() *Android {
+	entry := memoized("\x00android")
+	if entry == nil {
+		return AndroidFromRoot("/")
+	}
+	entry.once.Do(func() {
+		entry.value = AndroidFromRoot("/")
+	})
+	return entry.value.(*Android)
+}
+
+// AndroidFromRoot reads the build.prop files below root, which may be the
+// mount point of an extracted Android system image. On the running system
+// (root "/") properties missing from the files are asked from getprop.
+func AndroidFromRoot(root string) *Android {
+	props := map[string]string{}
+	for _, v := range buildPropFiles {
+		for key, value := range parseBuildProp(readFile(filepath.Join(root, v))) {
+			if _, ok := props[key]; !ok {
+				props[key] = value
+			}
+		}
+	}
+	useGetprop := root == "/" && existCmd("getprop")
+
+	prop := func(keys ...string) string {
+		for _, k := range keys {
+			if v := props[k]; !emptyStr(v) {
+				return v
+			}
+		}
+		if useGetprop {
+			return getprop(keys[0])
+		}
+		return ""
+	}
+
+	android := Android{
+		Release:       prop("ro.build.version.release", "ro.system.build.version.release"),
+		SDK:           prop("ro.build.version.sdk", "ro.system.build.version.sdk"),
+		SecurityPatch: prop("ro.build.version.security_patch", "ro.vendor.build.security_patch"),
+		Brand:         prop("ro.product.brand", "ro.product.vendor.brand", "ro.product.system.brand", "ro.product.product.brand"),
+		Model:         prop("ro.product.model", "ro.product.vendor.model", "ro.product.system.model", "ro.product.product.model"),
+		Device:        prop("ro.product.device", "ro.product.vendor.device", "ro.product.system.device", "ro.product.product.device"),
+		Fingerprint:   prop("ro.build.fingerprint", "ro.system.build.fingerprint", "ro.vendor.build.fingerprint"),
+		ABIs:          []string{},
+	}
+	abis := prop("ro.product.cpu.abilist", "ro.vendor.product.cpu.abilist", "ro.system.product.cpu.abilist")
+	for _, v := range strings.Split(abis, ",") {
+		if !emptyStr(v) {
+			android.ABIs = append(android.ABIs, v)
+		}
+	}
+	return &android
+}
+
+// parseBuildProp parses "key=value" lines, skipping comments and
+// "import" directives.
+func parseBuildProp(contents string) map[string]string {
+	props := map[string]string{}
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if emptyStr(line) || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "import ") {
+			continue
+		}
+		elem := strings.SplitN(line, "=", 2)
+		if len(elem) == 2 {
+			props[strings.TrimSpace(elem[0])] = strings.TrimSpace(elem[1])
+		}
+	}
+	return props
+}
+
+func getprop(key string) string {
+	out, err := runCmd("getprop", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,79 @@
+//
+// osinfo/chromeos.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"strings"
+)
+
+// ChromeOS is the Chrome OS host osinfo runs on, either directly or from
+// a Crostini Linux container or ARCVM Android guest. Version, Channel and
+// Board are only visible on the host itself.
+type ChromeOS struct {
+	Milestone string `json:"milestone,omitempty" yaml:"milestone,omitempty"`
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"`
+	Channel   string `json:"channel,omitempty" yaml:"channel,omitempty"`
+	Board     string `json:"board,omitempty" yaml:"board,omitempty"`
+	Crostini  bool   `json:"crostini,omitempty" yaml:"crostini,omitempty"`
+	ARCVM     bool   `json:"arcvm,omitempty" yaml:"arcvm,omitempty"`
+}
+
+// GetChromeOS returns the Chrome OS information, or nil when not running
+// on Chrome OS.
+func GetChromeOS() *ChromeOS {
+	if !isChromeOS() && !onChrome() {
+		return nil
+	}
+
+	chrome := chromeOSFromRelease(readFile("/etc/lsb-release"))
+	chrome.Crostini = isCrostini()
+	if isAndroid() {
+		prop := ""
+		if existCmd("getprop") {
+			prop = getprop("ro.boot.arcvm")
+		}
+		chrome.ARCVM = isARCVM(parseCmdline(readFile("/proc/cmdline")), prop)
+	}
+	if emptyStr(chrome.Milestone) {
+		chrome.Milestone = strings.TrimSpace(readFile("/dev/.cros_milestone"))
+	}
+	return &chrome
+}
+
+// chromeOSFromRelease reads the CHROMEOS_RELEASE_* keys of /etc/lsb-release.
+func chromeOSFromRelease(contents string) ChromeOS {
+	release := parseKeyValue(contents)
+	return ChromeOS{
+		Milestone: release["CHROMEOS_RELEASE_CHROME_MILESTONE"],
+		Version:   release["CHROMEOS_RELEASE_VERSION"],
+		Channel:   strings.TrimSuffix(release["CHROMEOS_RELEASE_TRACK"], "-channel"),
+		Board:     release["CHROMEOS_RELEASE_BOARD"],
+	}
+}
+
+// isARCVM reports whether this Android system is the ARCVM guest, which
+// Chrome OS boots with androidboot.arcvm=1 (read back as ro.boot.arcvm).
+func isARCVM(cmdline map[string]string, prop string) bool {
+	return prop == "1" || cmdline["androidboot.arcvm"] == "1"
+}
+
+// isCrostini reports whether this is the Crostini (Linux on Chromebook)
+// container: the host exposes its milestone and runs the sommelier proxy.
+func isCrostini() bool {
+	return isFile("/dev/.cros_milestone") || existCmd("sommelier") ||
+		isDir("/opt/google/cros-containers")
+}
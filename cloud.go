@@ -0,0 +1,101 @@
+//
+// osinfo/cloud.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"os"
+	"strings"
+)
+
+// azureAssetTag is the DMI chassis asset tag of every Azure virtual machine.
+const azureAssetTag = "7783-7084-3265-9085-8269-3286-77"
+
+// Cloud is the cloud platform the system runs on. Provider and Platform
+// use the values of the OpenTelemetry cloud.provider and cloud.platform
+// attributes ("aws" and "aws_ec2", "gcp" and "gcp_cloud_run", ...).
+// Region and MachineType are filled in only when they are known without
+// asking a metadata service.
+type Cloud struct {
+	Provider    string `json:"provider" yaml:"provider"`
+	Platform    string `json:"platform,omitempty" yaml:"platform,omitempty"`
+	Region      string `json:"region,omitempty" yaml:"region,omitempty"`
+	MachineType string `json:"machine_type,omitempty" yaml:"machine_type,omitempty"`
+}
+
+// cloudEnvs are the environment variables that serverless platforms set,
+// in the order they are checked: Azure Functions set the variables of App
+// Service too.
+var cloudEnvs = []struct {
+	env      string
+	provider string
+	platform string
+	region   string
+}{
+	{"AWS_LAMBDA_FUNCTION_NAME", "aws", "aws_lambda", "AWS_REGION"},
+	{"ECS_CONTAINER_METADATA_URI_V4", "aws", "aws_ecs", "AWS_REGION"},
+	{"ECS_CONTAINER_METADATA_URI", "aws", "aws_ecs", "AWS_REGION"},
+	{"K_SERVICE", "gcp", "gcp_cloud_run", ""},
+	{"FUNCTION_TARGET", "gcp", "gcp_cloud_functions", ""},
+	{"GAE_SERVICE", "gcp", "gcp_app_engine", ""},
+	{"FUNCTIONS_WORKER_RUNTIME", "azure", "azure_functions", "REGION_NAME"},
+	{"WEBSITE_SITE_NAME", "azure", "azure_app_service", "REGION_NAME"},
+}
+
+// GetCloud returns the cloud platform, or nil if none is detected. The
+// environment of the current process is checked first, then the DMI
+// strings of the virtual machine.
+func GetCloud() *Cloud {
+	if c := cloudFromEnv(os.Getenv); c != nil {
+		return c
+	}
+	return cloudFromDMI(dmiValue("sys_vendor"), dmiValue("product_name"),
+		dmiValue("bios_vendor"), dmiValue("chassis_asset_tag"))
+}
+
+func cloudFromEnv(getenv func(string) string) *Cloud {
+	for _, e := range cloudEnvs {
+		if emptyStr(getenv(e.env)) {
+			continue
+		}
+		c := Cloud{Provider: e.provider, Platform: e.platform}
+		if !emptyStr(e.region) {
+			c.Region = getenv(e.region)
+		}
+		return &c
+	}
+	return nil
+}
+
+// cloudFromDMI detects virtual machines. EC2 reports the instance type as
+// the product name.
+func cloudFromDMI(sysVendor string, productName string, biosVendor string, assetTag string) *Cloud {
+	switch {
+	case sysVendor == "Amazon EC2":
+		return &Cloud{Provider: "aws", Platform: "aws_ec2", MachineType: productName}
+	case strings.Contains(biosVendor, "Amazon EC2"):
+		return &Cloud{Provider: "aws", Platform: "aws_ec2"}
+	case productName == "Google Compute Engine":
+		return &Cloud{Provider: "gcp", Platform: "gcp_compute_engine"}
+	case sysVendor == "Microsoft Corporation" && assetTag == azureAssetTag:
+		return &Cloud{Provider: "azure", Platform: "azure_vm"}
+	case sysVendor == "Alibaba Cloud":
+		return &Cloud{Provider: "alibaba_cloud", Platform: "alibaba_cloud_ecs"}
+	case sysVendor == "Tencent Cloud":
+		return &Cloud{Provider: "tencent_cloud", Platform: "tencent_cloud_cvm"}
+	}
+	return nil
+}
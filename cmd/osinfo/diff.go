@@ -0,0 +1,100 @@
+//
+// osinfo/cmd/osinfo/diff.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/nao1215/osinfo"
+)
+
+// runDiff runs "osinfo diff OLD NEW", which prints what changed between
+// two snapshots (files written by "osinfo --json" or Snapshot.Save).
+func runDiff(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("osinfo diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: osinfo diff [--json] [--volatile] OLD.json NEW.json")
+		flags.PrintDefaults()
+	}
+	jsonOut := flags.Bool("json", false, "print the changes as JSON")
+	volatile := flags.Bool("volatile", false, "also print changes of uptime, boot time, power supplies, sensors, support status, pending reboots and kernel module users")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return exitError
+	}
+
+	before, err := osinfo.LoadSnapshot(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, "osinfo: "+err.Error())
+		return exitError
+	}
+	after, err := osinfo.LoadSnapshot(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(stderr, "osinfo: "+err.Error())
+		return exitError
+	}
+
+	changes, err := osinfo.Diff(before.OsInfo, after.OsInfo)
+	if err != nil {
+		fmt.Fprintln(stderr, "osinfo: "+err.Error())
+		return exitError
+	}
+	if !*jsonOut {
+		printChanges(stdout, changes, *volatile)
+		return exitOK
+	}
+	if !*volatile {
+		changes = changes.Identity()
+	}
+	if err := printChangesJSON(stdout, changes); err != nil {
+		fmt.Fprintln(stderr, "osinfo: "+err.Error())
+		return exitError
+	}
+	return exitOK
+}
+
+// printChanges prints one change per line, the volatile ones after the
+// others under their own heading.
+func printChanges(w io.Writer, changes osinfo.Changes, volatile bool) {
+	for _, c := range changes.Identity() {
+		fmt.Fprintln(w, c)
+	}
+	if !volatile || len(changes.Volatile()) == 0 {
+		return
+	}
+
+	if len(changes.Identity()) > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "Volatile:")
+	for _, c := range changes.Volatile() {
+		fmt.Fprintln(w, c)
+	}
+}
+
+func printChangesJSON(w io.Writer, changes osinfo.Changes) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(changes)
+}
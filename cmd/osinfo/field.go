@@ -0,0 +1,179 @@
+//
+// osinfo/cmd/osinfo/field.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nao1215/osinfo"
+)
+
+// field is one line of the text output. name is also the JSON key of the
+// OsInfo section it shows. Optional fields are left out of the default
+// layout when they are empty instead of being reported as missing, and
+// extra fields are only printed when asked for with --only.
+type field struct {
+	name     string
+	label    string
+	optional bool
+	extra    bool
+	value    func(info osinfo.OsInfo) string
+}
+
+var fields = []field{
+	{"os", "Kernel name", true, true, func(info osinfo.OsInfo) string { return info.Os }},
+	{"distro", "OS", false, false, func(info osinfo.OsInfo) string {
+		return strings.TrimSpace(info.Distro + " " + info.Kernel.Arch)
+	}},
+	{"model", "Host", true, false, func(info osinfo.OsInfo) string { return info.Model }},
+	{"kernel", "Kernel", false, false, func(info osinfo.OsInfo) string { return info.Kernel.Ver }},
+	{"uptime", "Uptime", false, false, func(info osinfo.OsInfo) string { return info.Uptime }},
+	{"shell", "Shell", false, false, func(info osinfo.OsInfo) string { return strings.TrimSpace(info.Shell) }},
+	{"displays", "Resolution", true, false, resolution},
+	{"power", "Battery", true, false, battery},
+	{"init", "Init", true, false, func(info osinfo.OsInfo) string {
+		return strings.TrimSpace(info.Init.Name + " " + info.Init.Version)
+	}},
+	{"locale", "Locale", true, false, func(info osinfo.OsInfo) string { return info.Locale.Categories["LC_CTYPE"] }},
+	{"firmware", "Firmware", true, false, func(info osinfo.OsInfo) string {
+		return strings.TrimSpace(info.Firmware.BootMode + " " + info.Firmware.Bootloader)
+	}},
+	{"wsl", "WSL", true, false, func(info osinfo.OsInfo) string {
+		if info.WSL == nil {
+			return ""
+		}
+		return strings.TrimSpace(fmt.Sprintf("WSL%d on Windows %s", info.WSL.Version, info.WSL.WindowsBuild))
+	}},
+	{"chromeos", "Chrome OS", true, false, func(info osinfo.OsInfo) string {
+		if info.ChromeOS == nil {
+			return ""
+		}
+		return strings.TrimSpace("M" + info.ChromeOS.Milestone + " " + info.ChromeOS.Channel)
+	}},
+	{"mac", "macOS", true, false, func(info osinfo.OsInfo) string {
+		if info.Mac == nil {
+			return ""
+		}
+		return strings.TrimSpace(info.Mac.Name + " " + info.Mac.Ver + " " + info.Mac.BuildVer)
+	}},
+}
+
+func fieldNames() []string {
+	names := []string{}
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+// selectFields returns the fields named in the comma separated list, or
+// the default layout if the list is empty.
+func selectFields(only string) ([]field, error) {
+	if strings.TrimSpace(only) == "" {
+		selected := []field{}
+		for _, f := range fields {
+			if !f.extra {
+				selected = append(selected, f)
+			}
+		}
+		return selected, nil
+	}
+
+	selected := []field{}
+	for _, name := range strings.Split(only, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, f := range fields {
+			if f.name == name {
+				selected = append(selected, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %q (available: %s)", name, strings.Join(fieldNames(), ","))
+		}
+	}
+	return selected, nil
+}
+
+// configFields returns the text lines of the fields named in the config
+// file, in the order of the default layout. Config fields without a text
+// line (e.g. "sensors") are only printed by --json and --yaml.
+func configFields(names []string) []field {
+	selected := []field{}
+	for _, f := range fields {
+		for _, name := range names {
+			if f.name == name {
+				selected = append(selected, f)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// applyLabels renames the fields with the labels of the config file.
+func applyLabels(selected []field, labels map[string]string) []field {
+	for i, f := range selected {
+		if label, ok := labels[f.name]; ok {
+			selected[i].label = label
+		}
+	}
+	return selected
+}
+
+// missingFields returns the names of the fields that could not be detected.
+// Without --only, optional fields are not expected on every machine.
+func missingFields(info osinfo.OsInfo, selected []field, only bool) []string {
+	missing := []string{}
+	for _, f := range selected {
+		if (only || !f.optional) && f.value(info) == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	return missing
+}
+
+func resolution(info osinfo.OsInfo) string {
+	modes := []string{}
+	for _, v := range info.Displays {
+		if v.PreferredMode == "" {
+			continue
+		}
+		mode := v.PreferredMode
+		if v.RefreshRate > 0 {
+			mode = mode + " @ " + strconv.FormatFloat(v.RefreshRate, 'f', -1, 64) + "Hz"
+		}
+		modes = append(modes, mode)
+	}
+	return strings.Join(modes, ", ")
+}
+
+func battery(info osinfo.OsInfo) string {
+	batteries := []string{}
+	for _, v := range info.Power.Batteries() {
+		if v.Capacity == nil {
+			batteries = append(batteries, "["+v.Status+"]")
+			continue
+		}
+		batteries = append(batteries, strconv.Itoa(*v.Capacity)+"% ["+v.Status+"]")
+	}
+	return strings.Join(batteries, ", ")
+}
@@ -0,0 +1,226 @@
+//
+// osinfo/cmd/osinfo/main.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nao1215/osinfo"
+	"github.com/nao1215/osinfo/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// exitOK : all requested fields were collected
+	exitOK = iota
+	// exitError : usage or output error
+	exitError
+	// exitPartial : output was printed, but some requested fields are unknown
+	exitPartial
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "diff" {
+		return runDiff(args[1:], stdout, stderr)
+	}
+
+	flags := flag.NewFlagSet("osinfo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jsonOut := flags.Bool("json", false, "print as JSON")
+	yamlOut := flags.Bool("yaml", false, "print as YAML")
+	only := flags.String("only", "", "comma separated list of fields to print ("+strings.Join(fieldNames(), ",")+")")
+	noColor := flags.Bool("no-color", false, "disable ANSI colors (also disabled by NO_COLOR)")
+	logoSize := flags.String("logo", "large", "logo size beside the text output (large, small or none)")
+	logoDistro := flags.String("logo-distro", "", "print the logo of another distribution (os-release ID or name)")
+	textfile := flags.String("textfile", "", "write Prometheus metrics to a node_exporter textfile (*.prom) instead of printing")
+	config := flags.String("config", "", "config file (default "+osinfo.ConfigPath()+")")
+	tmpl := flags.String("template", "", "render with a built-in template ("+strings.Join(osinfo.Templates(), ",")+") or a template file")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	if *jsonOut && *yamlOut {
+		fmt.Fprintln(stderr, "osinfo: --json and --yaml are mutually exclusive")
+		return exitError
+	}
+	if *textfile != "" && (*jsonOut || *yamlOut || *tmpl != "") {
+		fmt.Fprintln(stderr, "osinfo: --textfile can not be combined with --json, --yaml or --template")
+		return exitError
+	}
+	if *tmpl != "" && (*jsonOut || *yamlOut || *only != "") {
+		fmt.Fprintln(stderr, "osinfo: --template can not be combined with --json, --yaml or --only")
+		return exitError
+	}
+	logoSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "logo" || f.Name == "logo-distro" {
+			logoSet = true
+		}
+	})
+	source, err := templateSource(*tmpl)
+	if err != nil {
+		fmt.Fprintln(stderr, "osinfo: "+err.Error())
+		return exitError
+	}
+
+	opts, err := loadOptions(*config)
+	if err != nil {
+		fmt.Fprintln(stderr, "osinfo: "+err.Error())
+		return exitError
+	}
+	selected, err := selectFields(*only)
+	if err != nil {
+		fmt.Fprintln(stderr, "osinfo: "+err.Error())
+		return exitError
+	}
+
+	// keys are the JSON keys to print, nil for all of them. --only
+	// overrides the fields of the config file.
+	var keys []string
+	switch {
+	case *only != "":
+		for _, f := range selected {
+			keys = append(keys, f.name)
+		}
+		opts.Fields = keys
+	case len(opts.Fields) > 0:
+		keys = opts.Fields
+		selected = configFields(opts.Fields)
+	}
+	selected = applyLabels(selected, opts.Labels)
+
+	info := osinfo.GetWithOptions(opts)
+	if *textfile != "" {
+		if err := prometheus.WriteTextfile(*textfile, info); err != nil {
+			fmt.Fprintln(stderr, "osinfo: "+err.Error())
+			return exitError
+		}
+		return exitOK
+	}
+
+	missing := []string{}
+	if source == "" {
+		missing = missingFields(info, selected, *only != "")
+	}
+
+	color := !*noColor && os.Getenv("NO_COLOR") == ""
+	switch {
+	case source != "":
+		// Built-in and user templates print without a logo unless
+		// --logo or --logo-distro asks for one.
+		var logo *osinfo.Logo
+		var small bool
+		if logoSet {
+			logo, small, err = selectLogo(info, *logoSize, *logoDistro)
+			if err != nil {
+				break
+			}
+		}
+		err = printTemplate(stdout, info, source, color, logo, small)
+	case *jsonOut:
+		err = printJSON(stdout, info, keys)
+	case *yamlOut:
+		err = printYAML(stdout, info, keys)
+	default:
+		var logo *osinfo.Logo
+		var small bool
+		if *only == "" {
+			logo, small, err = selectLogo(info, *logoSize, *logoDistro)
+			if err != nil {
+				break
+			}
+		}
+		printText(stdout, info, selected, color, logo, small)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "osinfo: "+err.Error())
+		return exitError
+	}
+
+	if len(missing) > 0 {
+		fmt.Fprintln(stderr, "osinfo: could not detect "+strings.Join(missing, ", "))
+		return exitPartial
+	}
+	return exitOK
+}
+
+// loadOptions loads the config file given with --config, or the default
+// config file if it exists.
+func loadOptions(path string) (osinfo.Options, error) {
+	if path == "" {
+		return osinfo.LoadDefaultOptions()
+	}
+	return osinfo.LoadOptions(path)
+}
+
+// filtered returns the given encoded keys only.
+func filtered(info osinfo.OsInfo, keys []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	all := map[string]interface{}{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{"schema_version": all["schema_version"]}
+	for _, key := range keys {
+		if v, ok := all[key]; ok {
+			out[key] = v
+		}
+	}
+	return out, nil
+}
+
+func printJSON(w io.Writer, info osinfo.OsInfo, keys []string) error {
+	var v interface{} = info
+	if keys != nil {
+		m, err := filtered(info, keys)
+		if err != nil {
+			return err
+		}
+		v = m
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func printYAML(w io.Writer, info osinfo.OsInfo, keys []string) error {
+	var v interface{} = info
+	if keys != nil {
+		m, err := filtered(info, keys)
+		if err != nil {
+			return err
+		}
+		v = m
+	}
+
+	encoder := yaml.NewEncoder(w)
+	defer encoder.Close()
+	return encoder.Encode(v)
+}
@@ -0,0 +1,143 @@
+//
+// osinfo/cmd/osinfo/main_test.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/osinfo"
+)
+
+func TestRunJSONOnly(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	code := run([]string{"--json", "--only", "distro,kernel"}, stdout, stderr)
+	if code != exitOK && code != exitPartial {
+		t.Fatalf("run() = %d, stderr = %s", code, stderr.String())
+	}
+
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"schema_version", "distro", "kernel"} {
+		if _, ok := out[key]; !ok {
+			t.Errorf("missing %q in %s", key, stdout.String())
+		}
+	}
+	if _, ok := out["uptime"]; ok {
+		t.Errorf("unexpected uptime in %s", stdout.String())
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.toml")
+	contents := `fields = ["distro", "kernel"]
+
+[labels]
+distro = "Distribution"
+`
+	if err := os.WriteFile(config, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	if code := run([]string{"--config", config, "--no-color", "--logo", "none"}, stdout, &bytes.Buffer{}); code != exitOK {
+		t.Fatalf("run() = %d, output %s", code, stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "Distribution: ") || strings.Contains(stdout.String(), "Uptime") {
+		t.Errorf("unexpected output %s", stdout.String())
+	}
+	// The host name is an identifier, which the config does not include.
+	if title := osinfo.GetUsername() + "@localhost\n"; !strings.HasPrefix(stdout.String(), title) {
+		t.Errorf("output %s does not start with %q", stdout.String(), title)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	before := osinfo.Snapshot{OsInfo: osinfo.OsInfo{
+		SchemaVersion: osinfo.SchemaVersion,
+		Kernel:        osinfo.Kernel{Ver: "5.15.0-91-generic"},
+		Uptime:        "1 hour",
+	}}
+	after := before
+	after.Kernel.Ver = "5.15.0-92-generic"
+	after.Uptime = "2 minutes"
+	oldPath, newPath := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	if err := before.Save(oldPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := after.Save(newPath); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	if code := run([]string{"diff", oldPath, newPath}, stdout, &bytes.Buffer{}); code != exitOK {
+		t.Fatalf("run() = %d", code)
+	}
+	if want := "~ kernel.version: \"5.15.0-91-generic\" -> \"5.15.0-92-generic\"\n"; stdout.String() != want {
+		t.Errorf("diff printed %q, want %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"diff", "--json", "--volatile", oldPath, newPath}, stdout, &bytes.Buffer{}); code != exitOK {
+		t.Fatalf("run() = %d", code)
+	}
+	changes := osinfo.Changes{}
+	if err := json.Unmarshal(stdout.Bytes(), &changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Path != "kernel.version" || changes[1].Path != "uptime" || !changes[1].Volatile {
+		t.Errorf("diff --json printed %s", stdout.String())
+	}
+
+	for _, args := range [][]string{
+		{"diff"},
+		{"diff", oldPath},
+		{"diff", oldPath, filepath.Join(dir, "no-such.json")},
+		{"diff", "--no-such-flag", oldPath, newPath},
+	} {
+		if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}); code != exitError {
+			t.Errorf("run(%v) = %d, want %d", args, code, exitError)
+		}
+	}
+}
+
+func TestRunUsageError(t *testing.T) {
+	for _, args := range [][]string{
+		{"--only", "no-such-field"},
+		{"--json", "--yaml"},
+		{"--no-such-flag"},
+		{"--logo", "huge"},
+		{"--logo-distro", "no-such-distro"},
+		{"--template", "no-such-template"},
+		{"--template", "short", "--json"},
+		{"--config", "/no/such/config.toml"},
+		{"--textfile", "osinfo.txt"},
+		{"--textfile", "osinfo.prom", "--json"},
+	} {
+		if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}); code != exitError {
+			t.Errorf("run(%v) = %d, want %d", args, code, exitError)
+		}
+	}
+}
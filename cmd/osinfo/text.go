@@ -0,0 +1,117 @@
+//
+// osinfo/cmd/osinfo/text.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nao1215/osinfo"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+)
+
+// printText prints the neofetch style "user@host" title followed by
+// "Label: value" lines, beside the logo unless logo is nil. The title and
+// labels are painted in the first color of the logo, blue without one.
+func printText(w io.Writer, info osinfo.OsInfo, selected []field, color bool, logo *osinfo.Logo, small bool) {
+	accent := "\x1b[34m"
+	if logo != nil && len(logo.Colors) > 0 {
+		accent = logo.Color(1)
+	}
+	paint := func(s string) string {
+		if !color {
+			return s
+		}
+		return colorBold + accent + s + colorReset
+	}
+
+	title := osinfo.GetUsername() + "@" + osinfo.DisplayHostname(info)
+	lines := []string{paint(title), strings.Repeat("-", len(title))}
+	for _, f := range selected {
+		value := f.value(info)
+		if value == "" && f.optional {
+			continue
+		}
+		lines = append(lines, paint(f.label)+": "+value)
+	}
+
+	if logo == nil {
+		fmt.Fprintln(w, strings.Join(lines, "\n"))
+		return
+	}
+	fmt.Fprint(w, logo.Render(lines, small, color))
+}
+
+// templateSource returns the template for the --template option: the name
+// of a built-in template as is, otherwise the contents of the named file.
+func templateSource(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	for _, builtin := range osinfo.Templates() {
+		if name == builtin {
+			return name, nil
+		}
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("template %q is neither built-in (%s) nor a readable file: %w",
+			name, strings.Join(osinfo.Templates(), ","), err)
+	}
+	return string(data), nil
+}
+
+func printTemplate(w io.Writer, info osinfo.OsInfo, source string, color bool, logo *osinfo.Logo, small bool) error {
+	out, err := osinfo.RenderWithColor(info, source, color)
+	if err != nil {
+		return err
+	}
+	if logo == nil {
+		fmt.Fprint(w, out)
+		return nil
+	}
+	fmt.Fprint(w, logo.Render(strings.Split(strings.TrimRight(out, "\n"), "\n"), small, color))
+	return nil
+}
+
+// selectLogo returns the logo to print for the --logo and --logo-distro
+// options, or nil when no logo is printed.
+func selectLogo(info osinfo.OsInfo, mode string, distro string) (*osinfo.Logo, bool, error) {
+	switch mode {
+	case "none":
+		return nil, false, nil
+	case "large", "small":
+	default:
+		return nil, false, fmt.Errorf("unknown logo size %q (large, small or none)", mode)
+	}
+
+	logo := osinfo.LogoFor(info)
+	if distro != "" {
+		l, ok := osinfo.LookupLogo(distro)
+		if !ok {
+			return nil, false, fmt.Errorf("no logo for %q", distro)
+		}
+		logo = l
+	}
+	return &logo, mode == "small", nil
+}
@@ -0,0 +1,86 @@
+//
+// osinfo/collector.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import "sync"
+
+// Collector caches OsInfo for long-running processes. The static sections
+// (distro, model, DMI, ...) are collected once, and Refresh collects only
+// the dynamic ones (kernel modules and taint, uptime, power, sensors,
+// displays, image and support status). A Collector is safe for concurrent
+// use.
+//
+// The OsInfo values it returns share slices and maps with the cache, and
+// must not be modified.
+type Collector struct {
+	mu     sync.Mutex
+	opts   Options
+	env    collectEnv
+	info   OsInfo
+	cached bool
+}
+
+// NewCollector returns a Collector that collects what the options select.
+// Nothing is collected until the first call to Get or Refresh.
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
+
+// Get returns the cached OsInfo, collecting everything on the first call
+// and after Invalidate. The dynamic sections are as of the last collection.
+func (c *Collector) Get() OsInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.cached {
+		c.collect(false)
+	}
+	return c.info
+}
+
+// Refresh collects the dynamic sections again and returns the result.
+func (c *Collector) Refresh() OsInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.collect(c.cached)
+	return c.info
+}
+
+// Invalidate drops the cache, so that the next Get or Refresh collects the
+// static sections, and reads uname and sw_vers, again.
+func (c *Collector) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cached = false
+}
+
+// collect must be called with mu held. The previous OsInfo is copied, not
+// updated in place, because callers may still be reading it.
+func (c *Collector) collect(dynamicOnly bool) {
+	info := OsInfo{}
+	if dynamicOnly {
+		info = c.info
+	} else {
+		c.env = collectEnv{}
+	}
+	collect(&info, &c.env, c.opts, true, dynamicOnly)
+	c.info = info
+	c.cached = true
+}
@@ -0,0 +1,253 @@
+//
+// osinfo/devicetree.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const deviceTreeDir = "/sys/firmware/devicetree/base"
+
+// Board is the single board computer described by the device tree.
+type Board struct {
+	Model      string      `json:"model,omitempty" yaml:"model,omitempty"`
+	Compatible []string    `json:"compatible,omitempty" yaml:"compatible,omitempty"`
+	Vendor     string      `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	SoCVendor  string      `json:"soc_vendor,omitempty" yaml:"soc_vendor,omitempty"`
+	SoC        string      `json:"soc,omitempty" yaml:"soc,omitempty"`
+	Pi         *PiRevision `json:"raspberry_pi,omitempty" yaml:"raspberry_pi,omitempty"`
+}
+
+// PiRevision is the decoded Raspberry Pi board revision code.
+type PiRevision struct {
+	Code         string `json:"code,omitempty" yaml:"code,omitempty"`
+	Model        string `json:"model,omitempty" yaml:"model,omitempty"`
+	PCBRevision  string `json:"pcb_revision,omitempty" yaml:"pcb_revision,omitempty"`
+	Memory       string `json:"memory,omitempty" yaml:"memory,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty" yaml:"manufacturer,omitempty"`
+	Processor    string `json:"processor,omitempty" yaml:"processor,omitempty"`
+}
+
+// GetBoard returns the device tree board information, or nil if the
+// machine was not booted with a device tree.
+func GetBoard() *Board {
+	if !isDir(deviceTreeDir) {
+		return nil
+	}
+
+	board := Board{
+		Model:      deviceTreeString(readFile(deviceTreeDir + "/model")),
+		Compatible: deviceTreeStrings(readFile(deviceTreeDir + "/compatible")),
+	}
+	if len(board.Compatible) > 0 {
+		board.Vendor = compatibleVendor(board.Compatible[0])
+		soc := board.Compatible[len(board.Compatible)-1]
+		board.SoCVendor = compatibleVendor(soc)
+		board.SoC = compatibleDevice(soc)
+	}
+	if emptyStr(board.Model) && len(board.Compatible) > 0 {
+		board.Model = knownBoard(board.Compatible[0])
+	}
+
+	if code := piRevisionCode(); !emptyStr(code) &&
+		(strings.Contains(board.Model, "Raspberry Pi") || board.SoCVendor == "Broadcom") {
+		board.Pi = decodePiRevision(code)
+	}
+	return &board
+}
+
+// deviceTreeString trims the NUL terminator of a device tree string property.
+func deviceTreeString(prop string) string {
+	return strings.TrimSpace(strings.TrimRight(prop, "\x00"))
+}
+
+// deviceTreeStrings splits a NUL separated device tree string list.
+func deviceTreeStrings(prop string) []string {
+	list := []string{}
+	for _, v := range strings.Split(prop, "\x00") {
+		if !emptyStr(strings.TrimSpace(v)) {
+			list = append(list, strings.TrimSpace(v))
+		}
+	}
+	return list
+}
+
+func compatibleVendor(compatible string) string {
+	vendors := map[string]string{
+		"allwinner":   "Allwinner",
+		"amlogic":     "Amlogic",
+		"brcm":        "Broadcom",
+		"raspberrypi": "Raspberry Pi",
+		"rockchip":    "Rockchip",
+		"radxa":       "Radxa",
+		"pine64":      "Pine64",
+		"friendlyarm": "FriendlyElec",
+		"hardkernel":  "Hardkernel",
+		"xunlong":     "Xunlong",
+		"khadas":      "Khadas",
+		"libretech":   "Libre Computer",
+		"sinovoip":    "SinoVoip",
+		"olimex":      "Olimex",
+		"nvidia":      "NVIDIA",
+		"qcom":        "Qualcomm",
+		"fsl":         "NXP",
+		"ti":          "Texas Instruments",
+		"mediatek":    "MediaTek",
+		"starfive":    "StarFive",
+	}
+	prefix := strings.SplitN(compatible, ",", 2)[0]
+	if v, ok := vendors[prefix]; ok {
+		return v
+	}
+	return prefix
+}
+
+func compatibleDevice(compatible string) string {
+	elem := strings.SplitN(compatible, ",", 2)
+	if len(elem) < 2 {
+		return ""
+	}
+	return elem[1]
+}
+
+// knownBoard names common Rockchip/Allwinner/Amlogic boards whose device
+// tree has no model property.
+func knownBoard(compatible string) string {
+	boards := map[string]string{
+		"radxa,rock-5b":                "Radxa ROCK 5B",
+		"radxa,rock-4c-plus":           "Radxa ROCK 4C+",
+		"radxa,rockpi4b":               "Radxa ROCK Pi 4B",
+		"pine64,rockpro64":             "Pine64 RockPro64",
+		"pine64,pinebook-pro":          "Pine64 Pinebook Pro",
+		"pine64,quartz64-a":            "Pine64 Quartz64 Model A",
+		"friendlyarm,nanopi-r4s":       "FriendlyElec NanoPi R4S",
+		"friendlyarm,nanopi-r5s":       "FriendlyElec NanoPi R5S",
+		"xunlong,orangepi-5":           "Orange Pi 5",
+		"xunlong,orangepi-zero2":       "Orange Pi Zero2",
+		"xunlong,orangepi-pc":          "Orange Pi PC",
+		"pine64,pine64-plus":           "Pine64 A64+",
+		"sinovoip,bpi-m2-zero":         "Banana Pi BPI-M2 Zero",
+		"olimex,a20-olinuxino-lime2":   "Olimex A20-OLinuXino-LIME2",
+		"hardkernel,odroid-n2":         "ODROID-N2",
+		"hardkernel,odroid-n2-plus":    "ODROID-N2+",
+		"hardkernel,odroid-c4":         "ODROID-C4",
+		"khadas,vim3":                  "Khadas VIM3",
+		"libretech,aml-s905x-cc":       "Libre Computer AML-S905X-CC",
+		"amlogic,s400":                 "Amlogic Meson AXG S400",
+		"raspberrypi,5-model-b":        "Raspberry Pi 5 Model B",
+		"raspberrypi,4-model-b":        "Raspberry Pi 4 Model B",
+		"raspberrypi,3-model-b-plus":   "Raspberry Pi 3 Model B+",
+		"raspberrypi,model-zero-2-w":   "Raspberry Pi Zero 2 W",
+		"raspberrypi,4-compute-module": "Raspberry Pi Compute Module 4",
+	}
+	return boards[compatible]
+}
+
+// piRevisionCode returns the Raspberry Pi revision code as a hex string.
+func piRevisionCode() string {
+	for _, line := range strings.Split(readFile("/proc/cpuinfo"), "\n") {
+		if strings.HasPrefix(line, "Revision") {
+			elem := strings.SplitN(line, ":", 2)
+			if len(elem) == 2 {
+				return strings.TrimSpace(elem[1])
+			}
+		}
+	}
+
+	rev := readFile("/proc/device-tree/system/linux,revision")
+	if len(rev) == 4 {
+		return fmt.Sprintf("%x", binary.BigEndian.Uint32([]byte(rev)))
+	}
+	return ""
+}
+
+// decodePiRevision decodes both the old style (0002-0015) and the new style
+// (NOQuuuWuFMMMCCCCPPPPTTTTTTTTRRRR) Raspberry Pi revision codes.
+func decodePiRevision(code string) *PiRevision {
+	num, err := strconv.ParseUint(code, 16, 32)
+	if err != nil {
+		return nil
+	}
+
+	if num&(1<<23) == 0 {
+		return oldPiRevision(code, num&0xffffff)
+	}
+
+	types := map[uint64]string{
+		0x00: "A", 0x01: "B", 0x02: "A+", 0x03: "B+", 0x04: "2B", 0x05: "Alpha",
+		0x06: "CM1", 0x08: "3B", 0x09: "Zero", 0x0a: "CM3", 0x0c: "Zero W",
+		0x0d: "3B+", 0x0e: "3A+", 0x10: "CM3+", 0x11: "4B", 0x12: "Zero 2 W",
+		0x13: "400", 0x14: "CM4", 0x15: "CM4S", 0x17: "5", 0x18: "CM5",
+		0x19: "500", 0x1a: "CM5 Lite"}
+	processors := []string{"BCM2835", "BCM2836", "BCM2837", "BCM2711", "BCM2712"}
+	manufacturers := []string{"Sony UK", "Egoman", "Embest", "Sony Japan", "Embest", "Stadium"}
+	memories := []string{"256MB", "512MB", "1GB", "2GB", "4GB", "8GB", "16GB"}
+
+	rev := PiRevision{
+		Code:        code,
+		Model:       "Raspberry Pi " + types[(num>>4)&0xff],
+		PCBRevision: "1." + strconv.FormatUint(num&0xf, 10),
+	}
+	if i := (num >> 12) & 0xf; int(i) < len(processors) {
+		rev.Processor = processors[i]
+	}
+	if i := (num >> 16) & 0xf; int(i) < len(manufacturers) {
+		rev.Manufacturer = manufacturers[i]
+	}
+	if i := (num >> 20) & 0x7; int(i) < len(memories) {
+		rev.Memory = memories[i]
+	}
+	return &rev
+}
+
+func oldPiRevision(code string, num uint64) *PiRevision {
+	// model, PCB revision, memory, manufacturer
+	revisions := map[uint64][4]string{
+		0x02: {"B", "1.0", "256MB", "Egoman"},
+		0x03: {"B", "1.0", "256MB", "Egoman"},
+		0x04: {"B", "2.0", "256MB", "Sony UK"},
+		0x05: {"B", "2.0", "256MB", "Qisda"},
+		0x06: {"B", "2.0", "256MB", "Egoman"},
+		0x07: {"A", "2.0", "256MB", "Egoman"},
+		0x08: {"A", "2.0", "256MB", "Sony UK"},
+		0x09: {"A", "2.0", "256MB", "Qisda"},
+		0x0d: {"B", "2.0", "512MB", "Egoman"},
+		0x0e: {"B", "2.0", "512MB", "Sony UK"},
+		0x0f: {"B", "2.0", "512MB", "Egoman"},
+		0x10: {"B+", "1.2", "512MB", "Sony UK"},
+		0x11: {"CM1", "1.0", "512MB", "Sony UK"},
+		0x12: {"A+", "1.1", "256MB", "Sony UK"},
+		0x13: {"B+", "1.2", "512MB", "Embest"},
+		0x14: {"CM1", "1.0", "512MB", "Embest"},
+		0x15: {"A+", "1.1", "256MB", "Embest"}}
+
+	v, ok := revisions[num]
+	if !ok {
+		return nil
+	}
+	return &PiRevision{
+		Code:         code,
+		Model:        "Raspberry Pi " + v[0],
+		PCBRevision:  v[1],
+		Memory:       v[2],
+		Manufacturer: v[3],
+		Processor:    "BCM2835",
+	}
+}
@@ -0,0 +1,223 @@
+//
+// osinfo/display.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Display is a connected output. Manufacturer, Model, physical size and
+// preferred mode come from the EDID when the monitor provides one.
+type Display struct {
+	Connector     string   `json:"connector,omitempty" yaml:"connector,omitempty"`
+	Enabled       bool     `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Modes         []string `json:"modes,omitempty" yaml:"modes,omitempty"`
+	Manufacturer  string   `json:"manufacturer,omitempty" yaml:"manufacturer,omitempty"`
+	Model         string   `json:"model,omitempty" yaml:"model,omitempty"`
+	Serial        string   `json:"serial,omitempty" yaml:"serial,omitempty"`
+	WidthMM       int      `json:"width_mm,omitempty" yaml:"width_mm,omitempty"`
+	HeightMM      int      `json:"height_mm,omitempty" yaml:"height_mm,omitempty"`
+	PreferredMode string   `json:"preferred_mode,omitempty" yaml:"preferred_mode,omitempty"`
+	RefreshRate   float64  `json:"refresh_rate,omitempty" yaml:"refresh_rate,omitempty"`
+}
+
+// edid is the subset of an EDID 1.x base block osinfo cares about.
+type edid struct {
+	manufacturer string
+	productCode  uint16
+	model        string
+	serial       string
+	widthMM      int
+	heightMM     int
+	width        int
+	height       int
+	refreshRate  float64
+}
+
+// GetDisplays returns the connected outputs found under /sys/class/drm.
+// If there are none and useFallback is true, it asks the running X11 or
+// Wayland session through xrandr instead.
+func GetDisplays(useFallback bool) []Display {
+	displays := drmDisplays()
+	if len(displays) == 0 && useFallback {
+		displays = xrandrDisplays()
+	}
+	return displays
+}
+
+func drmDisplays() []Display {
+	displays := []Display{}
+	outputs, _ := filepath.Glob("/sys/class/drm/card*-*")
+	sort.Strings(outputs)
+
+	for _, dir := range outputs {
+		if sysfsString(filepath.Join(dir, "status")) != "connected" {
+			continue
+		}
+
+		display := Display{
+			Connector: drmConnectorName(filepath.Base(dir)),
+			Enabled:   sysfsString(filepath.Join(dir, "enabled")) == "enabled",
+			Modes:     strings.Fields(readFile(filepath.Join(dir, "modes"))),
+		}
+		if len(display.Modes) > 0 {
+			display.PreferredMode = display.Modes[0]
+		}
+
+		if e, err := parseEDID([]byte(readFile(filepath.Join(dir, "edid")))); err == nil {
+			display.Manufacturer = e.manufacturer
+			display.Model = e.model
+			if emptyStr(display.Model) {
+				display.Model = fmt.Sprintf("0x%04x", e.productCode)
+			}
+			display.Serial = e.serial
+			display.WidthMM = e.widthMM
+			display.HeightMM = e.heightMM
+			if e.width > 0 && e.height > 0 {
+				display.PreferredMode = strconv.Itoa(e.width) + "x" + strconv.Itoa(e.height)
+				display.RefreshRate = e.refreshRate
+			}
+		}
+		displays = append(displays, display)
+	}
+	return displays
+}
+
+// drmConnectorName strips the card prefix, e.g. "card0-HDMI-A-1" -> "HDMI-A-1".
+func drmConnectorName(name string) string {
+	elem := strings.SplitN(name, "-", 2)
+	if len(elem) < 2 {
+		return name
+	}
+	return elem[1]
+}
+
+// parseEDID decodes the vendor/product block, the screen size and the
+// preferred detailed timing of an EDID base block.
+func parseEDID(data []byte) (edid, error) {
+	header := []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+	if len(data) < 128 || !bytes.Equal(data[0:8], header) {
+		return edid{}, fmt.Errorf("invalid EDID")
+	}
+
+	e := edid{}
+	id := binary.BigEndian.Uint16(data[8:10])
+	e.manufacturer = string([]byte{
+		byte('A' - 1 + (id>>10)&0x1f),
+		byte('A' - 1 + (id>>5)&0x1f),
+		byte('A' - 1 + id&0x1f)})
+	e.productCode = binary.LittleEndian.Uint16(data[10:12])
+	e.widthMM = int(data[21]) * 10
+	e.heightMM = int(data[22]) * 10
+
+	for i := 54; i <= 108; i += 18 {
+		desc := data[i : i+18]
+		if desc[0] != 0 || desc[1] != 0 {
+			if e.width == 0 {
+				detailedTiming(&e, desc)
+			}
+			continue
+		}
+		switch desc[3] {
+		case 0xfc:
+			e.model = edidText(desc[5:])
+		case 0xff:
+			e.serial = edidText(desc[5:])
+		}
+	}
+	return e, nil
+}
+
+func detailedTiming(e *edid, desc []byte) {
+	clock := float64(binary.LittleEndian.Uint16(desc[0:2])) * 10000
+	hActive := int(desc[2]) | int(desc[4]&0xf0)<<4
+	hBlank := int(desc[3]) | int(desc[4]&0x0f)<<8
+	vActive := int(desc[5]) | int(desc[7]&0xf0)<<4
+	vBlank := int(desc[6]) | int(desc[7]&0x0f)<<8
+
+	e.width = hActive
+	e.height = vActive
+	if total := (hActive + hBlank) * (vActive + vBlank); total > 0 {
+		e.refreshRate = float64(int(clock/float64(total)*100+0.5)) / 100
+	}
+
+	widthMM := int(desc[12]) | int(desc[14]&0xf0)<<4
+	heightMM := int(desc[13]) | int(desc[14]&0x0f)<<8
+	if widthMM > 0 && heightMM > 0 {
+		e.widthMM = widthMM
+		e.heightMM = heightMM
+	}
+}
+
+func edidText(text []byte) string {
+	if i := bytes.IndexByte(text, 0x0a); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(string(text))
+}
+
+func xrandrDisplays() []Display {
+	displays := []Display{}
+	if (!hasEnvVar("DISPLAY") && !hasEnvVar("WAYLAND_DISPLAY")) || !existCmd("xrandr") {
+		return displays
+	}
+
+	out, err := runCmd("xrandr", "--current")
+	if err != nil {
+		return displays
+	}
+
+	output := regexp.MustCompile(`^(\S+) connected`)
+	mode := regexp.MustCompile(`^\s+(\d+x\d+)\s+(.*)`)
+	for _, line := range strings.Split(string(out), "\n") {
+		if m := output.FindStringSubmatch(line); m != nil {
+			displays = append(displays, Display{Connector: m[1]})
+			continue
+		}
+		m := mode.FindStringSubmatch(line)
+		if m == nil || len(displays) == 0 {
+			continue
+		}
+		display := &displays[len(displays)-1]
+		display.Modes = append(display.Modes, m[1])
+		if strings.Contains(m[2], "+") {
+			display.PreferredMode = m[1]
+		}
+		if strings.Contains(m[2], "*") {
+			display.Enabled = true
+			display.RefreshRate = xrandrRefreshRate(m[2])
+		}
+	}
+	return displays
+}
+
+func xrandrRefreshRate(rates string) float64 {
+	for _, v := range strings.Fields(rates) {
+		if strings.Contains(v, "*") {
+			rate, _ := strconv.ParseFloat(strings.Trim(v, "*+"), 64)
+			return rate
+		}
+	}
+	return 0
+}
@@ -18,13 +18,12 @@ package osinfo
 
 import (
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 )
 
-func distribution(os string, kernelName string, kernelVer string, mac macProductInfo) string {
+func distribution(os string, kernelName string, kernelVer string, mac MacInfo) string {
 	distro := "Unknown"
 
 	switch os {
@@ -92,11 +91,6 @@ func getDistroNameForBsdLinuxMinix(kernelName string, kernelVer string) string {
 		distro = othres(kernelName, kernelVer)
 	}
 
-	if onWindows(kernelVer) {
-		distro = distro + appendWindows()
-	} else if onChrome() {
-		distro = distro + appendChrome()
-	}
 	distro = formatDistroStr(distro)
 
 	if isUbuntuFlavor(distro) {
@@ -106,7 +100,7 @@ func getDistroNameForBsdLinuxMinix(kernelName string, kernelVer string) string {
 	return distro
 }
 
-func getDistroNameForMac(mac macProductInfo) string {
+func getDistroNameForMac(mac MacInfo) string {
 	distro := "macOS"
 	codes := map[string]string{
 		"10.4":  "Mac OS X Tiger",
@@ -134,12 +128,12 @@ func getDistroNameForMac(mac macProductInfo) string {
 	return distro + " " + mac.Ver + " " + mac.BuildVer
 }
 
-func getiPhoneDistroName(mac macProductInfo) string {
+func getiPhoneDistroName(mac MacInfo) string {
 	return "iOS " + mac.Ver
 }
 
 func getWindowsDistroName() string {
-	out, err := exec.Command("wmic", "os", "get", "Caption").Output()
+	out, err := runCmd("wmic", "os", "get", "Caption")
 	if err != nil {
 		return "Windows"
 	}
@@ -163,7 +157,7 @@ func getHaikuDistroName() string {
 }
 
 func getAIXDistroName() string {
-	out, err := exec.Command("oslevel").Output()
+	out, err := runCmd("oslevel")
 	if err != nil {
 		return "AIX"
 	}
@@ -258,11 +252,6 @@ func isOpenBSD(kernelName string) bool {
 	return kernelName == "OpenBSD"
 }
 
-func onWindows(kernelVer string) bool {
-	ver := readFile("/proc/version")
-	return strings.Contains(kernelVer, "Microsoft") || strings.Contains(ver, "Microsoft")
-}
-
 func onChrome() bool {
 	ver := readFile("/proc/version")
 	return strings.Contains(ver, "chrome-bot") || isFile("/dev/cros_ec")
@@ -315,7 +304,7 @@ func armbian() string {
 
 func siduction() string {
 	distro := "Siduction"
-	out, err := exec.Command("lsb_release", "-sic").Output()
+	out, err := runCmd("lsb_release", "-sic")
 	if err != nil {
 		return distro
 	}
@@ -333,7 +322,7 @@ func elbrus() string {
 
 func proxmox() string {
 	distro := "Proxmox VE"
-	out, err := exec.Command("pveversion").Output()
+	out, err := runCmd("pveversion")
 	if err != nil {
 		return distro
 	}
@@ -345,7 +334,7 @@ func proxmox() string {
 }
 
 func distroInfoFromLsbRelease() string {
-	out, err := exec.Command("lsb_release", "-sd").Output()
+	out, err := runCmd("lsb_release", "-sd")
 	if err != nil {
 		return ""
 	}
@@ -399,7 +388,7 @@ func sde() string {
 }
 
 func crux() string {
-	out, err := exec.Command("pveversion").Output()
+	out, err := runCmd("crux")
 	if err != nil {
 		return "CRUX"
 	}
@@ -415,11 +404,11 @@ func kslinux() string {
 }
 
 func android() string {
-	out, err := exec.Command("getprop", "ro.build.version.release").Output()
-	if err != nil {
+	release := localAndroid().Release
+	if emptyStr(release) {
 		return "Android"
 	}
-	return "Android " + string(out)
+	return "Android " + release
 }
 
 func chromeOS() string {
@@ -427,7 +416,7 @@ func chromeOS() string {
 }
 
 func guix() string {
-	out, err := exec.Command("guix", "-V").Output()
+	out, err := runCmd("guix", "-V")
 	if err != nil {
 		return "Guix System"
 	}
@@ -437,7 +426,7 @@ func guix() string {
 }
 
 func openBSD() string {
-	out, err := exec.Command("sysctl", "-n", "kern.version").Output()
+	out, err := runCmd("sysctl", "-n", "kern.version")
 	if err != nil {
 		return "OpenBSD"
 	}
@@ -470,6 +459,31 @@ func othres(kernelName string, kernelVer string) string {
 	return distro
 }
 
+// releaseID returns the os-release ID, VERSION_ID and NAME, or "macos",
+// the product version and the product name on macOS.
+func releaseID(os string, mac MacInfo) (string, string, string) {
+	if os == "Mac OS X" || os == "macOS" {
+		return "macos", mac.Ver, mac.Name
+	}
+	release := osRelease()
+	return release["ID"], release["VERSION_ID"], release["NAME"]
+}
+
+// osRelease returns the os-release keys, or an empty map if there is none.
+func osRelease() map[string]string {
+	return osReleaseIn("/")
+}
+
+// osReleaseIn reads the os-release file of the system mounted at root.
+func osReleaseIn(root string) map[string]string {
+	for _, v := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		if path := filepath.Join(root, v); isFile(path) {
+			return parseKeyValue(readFile(path))
+		}
+	}
+	return map[string]string{}
+}
+
 func releaseFiles() []string {
 	files, err := os.ReadDir("/etc")
 	if err != nil {
@@ -489,20 +503,6 @@ func releaseFiles() []string {
 	return paths
 }
 
-func appendWindows() string {
-	out, err := exec.Command("wmic.exe", "os", "get", "Version").Output()
-	if err != nil {
-		return " on Windows"
-	}
-	ver := strings.ReplaceAll(string(out), "Version", "")
-	ver = strings.TrimSpace(ver)
-	return " on Windows " + ver
-}
-
-func appendChrome() string {
-	return " on Chrome OS"
-}
-
 func ubuntuFlavor(distro string) string {
 	flavor := os.Getenv("XDG_CONFIG_DIRS")
 
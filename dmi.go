@@ -0,0 +1,113 @@
+//
+// osinfo/dmi.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const dmiDir = "/sys/devices/virtual/dmi/id"
+
+// DMI is the hardware identity reported by the DMI/SMBIOS tables.
+// ProductSerial and ProductUUID are only filled in by GetDMI(true).
+type DMI struct {
+	SysVendor      string `json:"sys_vendor,omitempty" yaml:"sys_vendor,omitempty"`
+	ProductName    string `json:"product_name,omitempty" yaml:"product_name,omitempty"`
+	ProductVersion string `json:"product_version,omitempty" yaml:"product_version,omitempty"`
+	ProductFamily  string `json:"product_family,omitempty" yaml:"product_family,omitempty"`
+	ProductSKU     string `json:"product_sku,omitempty" yaml:"product_sku,omitempty"`
+	BoardVendor    string `json:"board_vendor,omitempty" yaml:"board_vendor,omitempty"`
+	BoardName      string `json:"board_name,omitempty" yaml:"board_name,omitempty"`
+	BoardVersion   string `json:"board_version,omitempty" yaml:"board_version,omitempty"`
+	ChassisType    string `json:"chassis_type,omitempty" yaml:"chassis_type,omitempty"`
+	BIOSVendor     string `json:"bios_vendor,omitempty" yaml:"bios_vendor,omitempty"`
+	BIOSVersion    string `json:"bios_version,omitempty" yaml:"bios_version,omitempty"`
+	BIOSDate       string `json:"bios_date,omitempty" yaml:"bios_date,omitempty"`
+	ProductSerial  string `json:"product_serial,omitempty" yaml:"product_serial,omitempty"`
+	ProductUUID    string `json:"product_uuid,omitempty" yaml:"product_uuid,omitempty"`
+}
+
+// GetDMI returns the DMI/SMBIOS information, or nil if the machine has none.
+// Serial number and UUID identify a single machine, so they are read only
+// when includeIdentifiers is true (and usually require root).
+func GetDMI(includeIdentifiers bool) *DMI {
+	if !isDir(dmiDir) {
+		return nil
+	}
+
+	dmi := DMI{
+		SysVendor:      dmiValue("sys_vendor"),
+		ProductName:    dmiValue("product_name"),
+		ProductVersion: dmiValue("product_version"),
+		ProductFamily:  dmiValue("product_family"),
+		ProductSKU:     dmiValue("product_sku"),
+		BoardVendor:    dmiValue("board_vendor"),
+		BoardName:      dmiValue("board_name"),
+		BoardVersion:   dmiValue("board_version"),
+		ChassisType:    chassisType(dmiValue("chassis_type")),
+		BIOSVendor:     dmiValue("bios_vendor"),
+		BIOSVersion:    dmiValue("bios_version"),
+		BIOSDate:       dmiValue("bios_date"),
+	}
+	if includeIdentifiers {
+		dmi.ProductSerial = dmiValue("product_serial")
+		dmi.ProductUUID = dmiValue("product_uuid")
+	}
+	return &dmi
+}
+
+func dmiValue(name string) string {
+	value := readFile(filepath.Join(dmiDir, name))
+	value = removeDummyOEMinfoIfNeeded(value)
+	return strings.TrimSpace(value)
+}
+
+// chassisType decodes the SMBIOS chassis type number (SMBIOS 3.x, 7.4.1).
+func chassisType(code string) string {
+	num, err := strconv.Atoi(code)
+	if err != nil {
+		return ""
+	}
+
+	switch num {
+	case 3, 4, 5, 6, 7, 15, 16, 24, 35:
+		return "Desktop"
+	case 8, 9, 10, 14:
+		return "Laptop"
+	case 11:
+		return "Handheld"
+	case 12:
+		return "Docking station"
+	case 13:
+		return "All-in-one"
+	case 17, 23, 25, 26, 27, 28, 29:
+		return "Server"
+	case 30, 32:
+		return "Tablet"
+	case 31:
+		return "Convertible"
+	case 33, 34:
+		return "Embedded"
+	case 36:
+		return "Stick PC"
+	case 1, 18, 19, 20, 21, 22:
+		return "Other"
+	}
+	return "Unknown"
+}
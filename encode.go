@@ -0,0 +1,48 @@
+//
+// osinfo/encode.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"encoding/json"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSON returns the indented JSON encoding of the OsInfo.
+func (o OsInfo) JSON() ([]byte, error) {
+	return json.MarshalIndent(o, "", "  ")
+}
+
+// YAML returns the YAML encoding of the OsInfo.
+func (o OsInfo) YAML() ([]byte, error) {
+	return yaml.Marshal(o)
+}
+
+// MarshalJSON omits the end of life date when none is known, like the
+// YAML encoding does.
+func (s SupportStatus) MarshalJSON() ([]byte, error) {
+	type status SupportStatus
+	v := struct {
+		EOL *time.Time `json:"eol,omitempty"`
+		status
+	}{status: status(s)}
+	if !s.EOL.IsZero() {
+		v.EOL = &s.EOL
+	}
+	return json.Marshal(v)
+}
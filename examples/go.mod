@@ -3,3 +3,10 @@ module github.com/nao1215/osinfo/examples
 go 1.17
 
 require github.com/nao1215/osinfo v0.0.0-20211227102510-86471592ce01
+
+require (
+	github.com/BurntSushi/toml v1.2.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/nao1215/osinfo => ../
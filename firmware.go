@@ -0,0 +1,139 @@
+//
+// osinfo/firmware.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+const loaderVariable = "4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
+
+// Firmware is the firmware, boot mode and boot loader the machine booted with.
+type Firmware struct {
+	BootMode     string            `json:"boot_mode,omitempty" yaml:"boot_mode,omitempty"`
+	PlatformSize int               `json:"platform_size,omitempty" yaml:"platform_size,omitempty"`
+	Vendor       string            `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	Version      string            `json:"version,omitempty" yaml:"version,omitempty"`
+	Date         string            `json:"date,omitempty" yaml:"date,omitempty"`
+	Bootloader   string            `json:"bootloader,omitempty" yaml:"bootloader,omitempty"`
+	BootEntry    string            `json:"boot_entry,omitempty" yaml:"boot_entry,omitempty"`
+	Cmdline      map[string]string `json:"cmdline,omitempty" yaml:"cmdline,omitempty"`
+}
+
+// GetFirmware returns the firmware and boot information.
+func GetFirmware() Firmware {
+	firmware := Firmware{
+		BootMode: "BIOS",
+		Vendor:   dmiValue("bios_vendor"),
+		Version:  dmiValue("bios_version"),
+		Date:     dmiValue("bios_date"),
+		Cmdline:  parseCmdline(readFile("/proc/cmdline")),
+	}
+	if isDir("/sys/firmware/efi") {
+		firmware.BootMode = "UEFI"
+		firmware.PlatformSize = sysfsInt("/sys/firmware/efi/fw_platform_size")
+	} else if isDir(deviceTreeDir) && !isDir(dmiDir) {
+		firmware.BootMode = "Device Tree"
+	}
+	firmware.Bootloader, firmware.BootEntry = bootloader()
+	return firmware
+}
+
+func bootloader() (string, string) {
+	if info := efiVarString("LoaderInfo-" + loaderVariable); !emptyStr(info) {
+		return info, efiVarString("LoaderEntrySelected-" + loaderVariable)
+	}
+	if ver := deviceTreeString(readFile(deviceTreeDir + "/chosen/u-boot,version")); !emptyStr(ver) {
+		return ver, ""
+	}
+	if isDir(deviceTreeDir+"/chosen/bootloader") || isFile("/boot/firmware/start4.elf") ||
+		isFile("/boot/start4.elf") || isFile("/boot/start.elf") {
+		return "Raspberry Pi firmware", ""
+	}
+	if isDir("/boot/grub") || isDir("/boot/grub2") || hasGrubEFI() {
+		return "GRUB", ""
+	}
+	return "", ""
+}
+
+func hasGrubEFI() bool {
+	paths, _ := filepath.Glob("/boot/efi/EFI/*/grub*.efi")
+	return len(paths) > 0
+}
+
+// efiVarString decodes a UTF-16LE, NUL terminated EFI variable.
+func efiVarString(name string) string {
+	data := []byte(readFile(filepath.Join("/sys/firmware/efi/efivars", name)))
+	if len(data) < 6 {
+		return ""
+	}
+
+	data = data[4:]
+	chars := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		c := binary.LittleEndian.Uint16(data[i:])
+		if c == 0 {
+			break
+		}
+		chars = append(chars, c)
+	}
+	return string(utf16.Decode(chars))
+}
+
+// parseCmdline splits the kernel command line into parameters. Flags
+// without a value map to "", and a repeated parameter keeps its last value.
+// Everything after "--" is passed to init and is not a kernel parameter.
+func parseCmdline(cmdline string) map[string]string {
+	params := map[string]string{}
+	for _, v := range splitCmdline(strings.TrimSpace(cmdline)) {
+		if v == "--" {
+			break
+		}
+		elem := strings.SplitN(v, "=", 2)
+		if len(elem) == 2 {
+			params[elem[0]] = strings.Trim(elem[1], "\"")
+		} else {
+			params[elem[0]] = ""
+		}
+	}
+	return params
+}
+
+// splitCmdline splits on spaces outside of double quotes.
+func splitCmdline(cmdline string) []string {
+	params := []string{}
+	quoted := false
+	start := 0
+	for i, c := range cmdline {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ' ' && !quoted:
+			if i > start {
+				params = append(params, cmdline[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(cmdline) {
+		params = append(params, cmdline[start:])
+	}
+	return params
+}
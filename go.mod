@@ -1,3 +1,7 @@
 module github.com/nao1215/osinfo
 
 go 1.17
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/BurntSushi/toml v1.2.1
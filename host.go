@@ -0,0 +1,99 @@
+//
+// osinfo/host.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"os"
+	"os/user"
+	"strings"
+)
+
+// GetHostname returns the host name reported by the kernel, or "".
+func GetHostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// DisplayHostname returns the host name to print for info: its Hostname,
+// or "localhost" when the host name was withheld because
+// Options.IncludeIdentifiers is off.
+func DisplayHostname(info OsInfo) string {
+	if emptyStr(info.Hostname) {
+		return "localhost"
+	}
+	return info.Hostname
+}
+
+// GetUsername returns the name of the user running osinfo, or $USER when
+// the user database can not be read.
+func GetUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// GetMachineID returns the identifier the operating system assigned to this
+// installation: machine-id on Linux, hostid on the BSDs, IOPlatformUUID on
+// macOS and MachineGuid on Windows. It returns "" if there is none.
+func GetMachineID() string {
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id", "/etc/hostid"} {
+		if id := strings.TrimSpace(readFile(path)); !emptyStr(id) {
+			return id
+		}
+	}
+
+	if out, err := runCmd("ioreg", "-rd1", "-c", "IOPlatformExpertDevice"); err == nil {
+		return platformUUID(string(out))
+	}
+	if out, err := runCmd("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid"); err == nil {
+		return machineGUID(string(out))
+	}
+	return ""
+}
+
+// platformUUID returns IOPlatformUUID from the output of ioreg:
+//
+//	"IOPlatformUUID" = "4A3F2E1D-..."
+func platformUUID(ioreg string) string {
+	for _, line := range strings.Split(ioreg, "\n") {
+		if !strings.Contains(line, `"IOPlatformUUID"`) {
+			continue
+		}
+		elem := strings.SplitN(line, "=", 2)
+		if len(elem) == 2 {
+			return strings.Trim(strings.TrimSpace(elem[1]), `"`)
+		}
+	}
+	return ""
+}
+
+// machineGUID returns MachineGuid from the output of reg query:
+//
+//	MachineGuid    REG_SZ    6f1d...
+func machineGUID(reg string) string {
+	for _, line := range strings.Split(reg, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "MachineGuid" {
+			return fields[2]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,223 @@
+//
+// osinfo/image.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Image describes an immutable, image-based system (rpm-ostree, NixOS,
+// Ubuntu Core, SteamOS, openSUSE MicroOS). Deployment is the booted
+// deployment, generation, snap revision, A/B slot or snapshot, and
+// PendingReboot reports that a different one will be used on next boot.
+type Image struct {
+	Type          string `json:"type,omitempty" yaml:"type,omitempty"`
+	Immutable     bool   `json:"immutable,omitempty" yaml:"immutable,omitempty"`
+	Deployment    string `json:"deployment,omitempty" yaml:"deployment,omitempty"`
+	PendingReboot bool   `json:"pending_reboot,omitempty" yaml:"pending_reboot,omitempty"`
+}
+
+// GetImage returns the image information, or nil on a traditional,
+// package-managed system. Everything is read from on-disk state.
+func GetImage() *Image {
+	return ImageFromRoot("/")
+}
+
+// ImageFromRoot reads the image information of the system mounted at root.
+// Symbolic links are resolved below root, as in a chroot.
+func ImageFromRoot(root string) *Image {
+	release := osReleaseIn(root)
+	in := func(path string) string { return filepath.Join(root, path) }
+
+	var image *Image
+	if isFile(in("/run/ostree-booted")) {
+		image = ostreeImage(root)
+	} else if release["ID"] == "nixos" || isSymlink(in("/nix/var/nix/profiles/system")) {
+		// Not /run/current-system, which Guix System has as well.
+		image = nixosImage(root)
+	} else if release["ID"] == "ubuntu-core" || isFile(in("/var/lib/snapd/modeenv")) {
+		image = ubuntuCoreImage(root)
+	} else if release["ID"] == "steamos" {
+		image = steamOSImage(root)
+	} else if strings.Contains(release["ID"], "microos") || strings.Contains(release["VARIANT_ID"], "microos") ||
+		isFile(in("/etc/transactional-update.conf")) || isFile(in("/usr/etc/transactional-update.conf")) {
+		image = microOSImage(root)
+	}
+	return image
+}
+
+// ostreeImage compares the deployment booted through the "ostree=" kernel
+// parameter with the one the default boot loader entry points to. rpm-ostree
+// stages new deployments by default and writes the boot loader entries
+// only at shutdown; until then /run/ostree/staged-deployment exists.
+func ostreeImage(root string) *Image {
+	image := Image{Type: "rpm-ostree", Immutable: true}
+	booted := ostreeDeployment(root, parseCmdline(readFile(filepath.Join(root, "/proc/cmdline")))["ostree"])
+	image.Deployment = filepath.Base(booted)
+
+	entries, _ := filepath.Glob(filepath.Join(root, "/boot/loader/entries/ostree-*.conf"))
+	newest := 0
+	next := ""
+	for _, v := range entries {
+		conf := bootEntry(readFile(v))
+		ver, _ := strconv.Atoi(conf["version"])
+		if ver > newest {
+			newest = ver
+			next = ostreeDeployment(root, parseCmdline(conf["options"])["ostree"])
+		}
+	}
+	image.PendingReboot = isFile(filepath.Join(root, "/run/ostree/staged-deployment")) ||
+		(!emptyStr(next) && !emptyStr(booted) && next != booted)
+	return &image
+}
+
+// ostreeDeployment resolves /ostree/boot.N/... to /ostree/deploy/<os>/deploy/<checksum>.<serial>.
+func ostreeDeployment(root string, path string) string {
+	if emptyStr(path) {
+		return ""
+	}
+	resolved, err := evalSymlinksIn(root, path)
+	if err != nil {
+		return path
+	}
+	return resolved
+}
+
+// evalSymlinksIn is filepath.EvalSymlinks for a path of the system mounted
+// at root: absolute link targets are resolved below root. The result is
+// relative to root.
+func evalSymlinksIn(root string, path string) (string, error) {
+	resolved := "/"
+	rest := strings.Split(filepath.Clean("/"+path), "/")
+	for links := 0; len(rest) > 0; {
+		name := rest[0]
+		rest = rest[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, name)
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			if _, err := os.Lstat(filepath.Join(root, next)); err != nil {
+				return "", err
+			}
+			resolved = next
+			continue
+		}
+		if links++; links > 255 {
+			return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(resolved, target)
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+		resolved = "/"
+	}
+	return resolved, nil
+}
+
+// bootEntry parses a Boot Loader Specification entry ("key value" lines).
+func bootEntry(contents string) map[string]string {
+	entry := map[string]string{}
+	for _, line := range strings.Split(contents, "\n") {
+		elem := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(elem) == 2 {
+			entry[elem[0]] = strings.TrimSpace(elem[1])
+		}
+	}
+	return entry
+}
+
+// nixosImage reports the system profile generation. A generation newer than
+// the booted system becomes active on next boot.
+func nixosImage(root string) *Image {
+	image := Image{Type: "nixos", Immutable: true}
+	profile, err := os.Readlink(filepath.Join(root, "/nix/var/nix/profiles/system"))
+	if err == nil {
+		if m := regexp.MustCompile(`system-(\d+)-link`).FindStringSubmatch(profile); m != nil {
+			image.Deployment = m[1]
+		}
+	}
+
+	current, err1 := evalSymlinksIn(root, "/nix/var/nix/profiles/system")
+	booted, err2 := evalSymlinksIn(root, "/run/booted-system")
+	image.PendingReboot = err1 == nil && err2 == nil && current != booted
+	return &image
+}
+
+// ubuntuCoreImage reads the base snap from snapd's modeenv; a try_base is
+// a new base waiting for a reboot.
+func ubuntuCoreImage(root string) *Image {
+	image := Image{Type: "ubuntu-core", Immutable: true}
+	modeenv := parseKeyValue(readFile(filepath.Join(root, "/var/lib/snapd/modeenv")))
+	image.Deployment = strings.TrimSuffix(modeenv["base"], ".snap")
+	image.PendingReboot = !emptyStr(modeenv["try_base"])
+
+	if emptyStr(image.Deployment) {
+		cores, _ := filepath.Glob(filepath.Join(root, "/snap/core*/current"))
+		sort.Strings(cores)
+		if len(cores) > 0 {
+			if rev, err := os.Readlink(cores[len(cores)-1]); err == nil {
+				image.Deployment = filepath.Base(filepath.Dir(cores[len(cores)-1])) + "_" + rev
+			}
+		}
+	}
+	return &image
+}
+
+// steamOSImage reports the A/B root file system slot.
+func steamOSImage(root string) *Image {
+	image := Image{Type: "steamos", Immutable: true}
+	cmdline := parseCmdline(readFile(filepath.Join(root, "/proc/cmdline")))
+	for _, v := range []string{"rauc.slot", "steamos.slot"} {
+		if slot := cmdline[v]; !emptyStr(slot) {
+			image.Deployment = slot
+			return &image
+		}
+	}
+	if self, err := os.Readlink(filepath.Join(root, "/dev/disk/by-partsets/self")); err == nil {
+		image.Deployment = filepath.Base(self)
+	}
+	return &image
+}
+
+// microOSImage reports the btrfs snapshot mounted as root. transactional-update
+// creates /run/reboot-needed after preparing a new snapshot.
+func microOSImage(root string) *Image {
+	image := Image{Type: "transactional-update", Immutable: true}
+	for _, line := range strings.Split(readFile(filepath.Join(root, "/proc/self/mountinfo")), "\n") {
+		elem := strings.Fields(line)
+		if len(elem) > 4 && elem[4] == "/" {
+			if m := regexp.MustCompile(`/\.snapshots/(\d+)/snapshot`).FindStringSubmatch(elem[3]); m != nil {
+				image.Deployment = m[1]
+			}
+		}
+	}
+	image.PendingReboot = isFile(filepath.Join(root, "/run/reboot-needed"))
+	return &image
+}
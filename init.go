@@ -0,0 +1,103 @@
+//
+// osinfo/init.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Init is the init system and service manager running as PID 1.
+type Init struct {
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// GetInit returns the init system. Name is empty if it cannot be detected.
+func GetInit() Init {
+	initSys := Init{Name: initName("/")}
+	if initSys.Name == "systemd" {
+		initSys.Version = systemdVer()
+	}
+	return initSys
+}
+
+// initName detects the init system of the system mounted at root from the
+// name of PID 1 and the runtime directories of the service managers.
+func initName(root string) string {
+	in := func(path string) string { return filepath.Join(root, path) }
+	comm := strings.TrimSpace(readFile(in("/proc/1/comm")))
+	exe, _ := os.Readlink(in("/proc/1/exe"))
+	exe = filepath.Base(exe)
+
+	name := ""
+	if isDir(in("/run/systemd/system")) || comm == "systemd" || exe == "systemd" {
+		name = "systemd"
+	} else if isDir(in("/run/openrc")) || comm == "openrc-init" {
+		name = "OpenRC"
+	} else if comm == "runit" || isDir(in("/run/runit")) || isDir(in("/etc/runit/runsvdir")) {
+		name = "runit"
+	} else if strings.HasPrefix(comm, "s6-") || isDir(in("/run/s6")) || isDir(in("/run/s6-rc")) {
+		name = "s6"
+	} else if comm == "dinit" || exe == "dinit" {
+		name = "dinit"
+	} else if comm == "shepherd" || exe == "shepherd" {
+		name = "GNU Shepherd"
+	} else if isFile(in("/sbin/launchd")) {
+		name = "launchd"
+	} else if isUpstart(root) {
+		name = "Upstart"
+	} else if comm == "init" || exe == "init" {
+		name = "SysVinit"
+	}
+	return name
+}
+
+func isUpstart(root string) bool {
+	initctl := filepath.Join(root, "/sbin/initctl")
+	return isFile(initctl) && strings.Contains(readFile(initctl), "upstart")
+}
+
+// systemdVer returns the systemd version without spawning systemctl: the
+// shared library carries it in its file name, the binary in its data.
+func systemdVer() string {
+	libs := []string{
+		"/usr/lib/systemd/libsystemd-shared-*.so",
+		"/lib/systemd/libsystemd-shared-*.so",
+		"/usr/lib/*/systemd/libsystemd-shared-*.so",
+		"/usr/lib64/systemd/libsystemd-shared-*.so",
+	}
+	for _, pattern := range libs {
+		paths, _ := filepath.Glob(pattern)
+		for _, v := range paths {
+			ver := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(v), "libsystemd-shared-"), ".so")
+			if !emptyStr(ver) {
+				return ver
+			}
+		}
+	}
+
+	rep := regexp.MustCompile(`systemd (\d{3}[\w.~+-]*)`)
+	for _, v := range []string{"/usr/lib/systemd/systemd", "/lib/systemd/systemd"} {
+		if m := rep.FindStringSubmatch(readFile(v)); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+//
+// osinfo/kernel.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// KernelModule is a loaded module listed in /proc/modules.
+type KernelModule struct {
+	Name     string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Size     int      `json:"size,omitempty" yaml:"size,omitempty"`
+	RefCount int      `json:"ref_count,omitempty" yaml:"ref_count,omitempty"`
+	UsedBy   []string `json:"used_by,omitempty" yaml:"used_by,omitempty"`
+	State    string   `json:"state,omitempty" yaml:"state,omitempty"`
+	Taint    string   `json:"taint,omitempty" yaml:"taint,omitempty"`
+}
+
+// taintFlags is the meaning of each bit of /proc/sys/kernel/tainted.
+var taintFlags = []string{
+	"proprietary module",
+	"module force loaded",
+	"out-of-spec system",
+	"module force unloaded",
+	"machine check exception",
+	"bad page",
+	"user requested taint",
+	"kernel died recently",
+	"ACPI table overridden",
+	"kernel warning",
+	"staging driver",
+	"firmware workaround",
+	"out-of-tree module",
+	"unsigned module",
+	"soft lockup",
+	"live patched",
+	"auxiliary taint",
+	"struct randomization plugin",
+	"in-kernel test",
+}
+
+var (
+	kernelConfigMu    sync.Mutex
+	kernelConfigCache = map[string]map[string]string{}
+)
+
+func getKernel(utsname utsname) Kernel {
+	return Kernel{
+		Name:     utsname.sys,
+		Ver:      utsname.release,
+		Arch:     utsname.machine,
+		Tainted:  decodeTaint(sysfsString("/proc/sys/kernel/tainted")),
+		Modules:  kernelModules(readFile("/proc/modules")),
+		PageSize: os.Getpagesize(),
+		Flavour:  kernelFlavour(utsname.release),
+	}
+}
+
+// Config returns the value of a kernel build option such as "CONFIG_BPF"
+// ("y", "m", a string or number), or "" if it is not set. The build config
+// is read on first use from /proc/config.gz when Ver is the running kernel,
+// and from /boot/config-<Ver> otherwise.
+func (k Kernel) Config(name string) string {
+	kernelConfigMu.Lock()
+	defer kernelConfigMu.Unlock()
+
+	config, ok := kernelConfigCache[k.Ver]
+	if !ok {
+		config = parseKernelConfig(kernelConfigIn("/", k.Ver, uts().release))
+		kernelConfigCache[k.Ver] = config
+	}
+	return config[name]
+}
+
+// kernelConfigIn reads the build config of release below root. The
+// running kernel's config, /proc/config.gz, is used only for that kernel.
+func kernelConfigIn(root string, release string, running string) string {
+	if release == running {
+		if f, err := os.Open(filepath.Join(root, "/proc/config.gz")); err == nil {
+			defer f.Close()
+			if r, err := gzip.NewReader(f); err == nil {
+				if data, err := ioutil.ReadAll(r); err == nil {
+					return string(data)
+				}
+			}
+		}
+	}
+	return readFile(filepath.Join(root, "/boot/config-"+release))
+}
+
+func parseKernelConfig(config string) map[string]string {
+	options := map[string]string{}
+	for _, line := range strings.Split(config, "\n") {
+		if !strings.HasPrefix(line, "CONFIG_") {
+			continue
+		}
+		elem := strings.SplitN(line, "=", 2)
+		if len(elem) == 2 {
+			options[elem[0]] = strings.Trim(elem[1], "\"")
+		}
+	}
+	return options
+}
+
+func decodeTaint(tainted string) []string {
+	flags := []string{}
+	mask, err := strconv.ParseUint(tainted, 10, 64)
+	if err != nil {
+		return flags
+	}
+
+	for i, v := range taintFlags {
+		if mask&(1<<uint(i)) != 0 {
+			flags = append(flags, v)
+		}
+	}
+	return flags
+}
+
+// kernelModules parses lines like
+// "nvidia 56213504 2 nvidia_modeset, Live 0x0000000000000000 (POE)".
+func kernelModules(modules string) []KernelModule {
+	list := []KernelModule{}
+	for _, line := range strings.Split(modules, "\n") {
+		elem := strings.Fields(line)
+		if len(elem) < 5 {
+			continue
+		}
+
+		module := KernelModule{
+			Name:   elem[0],
+			UsedBy: []string{},
+			State:  elem[4],
+		}
+		module.Size, _ = strconv.Atoi(elem[1])
+		module.RefCount, _ = strconv.Atoi(elem[2])
+		for _, v := range strings.Split(elem[3], ",") {
+			if !emptyStr(v) && v != "-" {
+				module.UsedBy = append(module.UsedBy, v)
+			}
+		}
+		if len(elem) > 6 {
+			module.Taint = strings.Trim(elem[6], "()")
+		}
+		list = append(list, module)
+	}
+	return list
+}
+
+// kernelFlavour derives the distribution kernel flavour from the release
+// string, e.g. "5.15.0-1034-aws" -> "cloud". WSL kernels are told apart
+// like wslVersion does: only the WSL1 release has a capitalised
+// "-Microsoft", every WSL2 kernel has "-microsoft-standard".
+func kernelFlavour(release string) string {
+	switch {
+	case strings.Contains(strings.ToLower(release), "-microsoft-standard"),
+		strings.HasSuffix(strings.ToLower(release), "-wsl2"):
+		return "WSL2"
+	case strings.Contains(release, "-Microsoft"):
+		return "WSL1"
+	}
+
+	release = strings.ToLower(release)
+	flavours := []struct {
+		suffix  []string
+		flavour string
+	}{
+		{[]string{"-rt", "+rt", ".rt"}, "rt"},
+		{[]string{"-lowlatency"}, "lowlatency"},
+		{[]string{"-aws", "-azure", "-gcp", "-gke", "-oracle", "-ibm", "-kvm", "-cloud-", "-virtual"}, "cloud"},
+		{[]string{"-raspi", "-rpi", "+rpt"}, "raspi"},
+		{[]string{"-zen"}, "zen"},
+		{[]string{"-hardened"}, "hardened"},
+		{[]string{"-lts"}, "lts"},
+		{[]string{"-generic"}, "generic"},
+		{[]string{"-arch"}, "arch"},
+	}
+
+	for _, f := range flavours {
+		for _, v := range f.suffix {
+			if strings.Contains(release, v) {
+				return f.flavour
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+//
+// osinfo/locale.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// localeCategories is the list of locale categories in glibc order.
+var localeCategories = []string{
+	"LC_CTYPE",
+	"LC_NUMERIC",
+	"LC_TIME",
+	"LC_COLLATE",
+	"LC_MONETARY",
+	"LC_MESSAGES",
+	"LC_PAPER",
+	"LC_NAME",
+	"LC_ADDRESS",
+	"LC_TELEPHONE",
+	"LC_MEASUREMENT",
+	"LC_IDENTIFICATION",
+}
+
+// Locale is the locale, timezone and keyboard layout of the machine.
+// Lang and Categories are what this process uses; System is the default
+// configured for new sessions.
+type Locale struct {
+	Lang       string            `json:"lang,omitempty" yaml:"lang,omitempty"`
+	Categories map[string]string `json:"categories,omitempty" yaml:"categories,omitempty"`
+	System     string            `json:"system,omitempty" yaml:"system,omitempty"`
+	Timezone   string            `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Keymap     string            `json:"keymap,omitempty" yaml:"keymap,omitempty"`
+	X11Layout  string            `json:"x11_layout,omitempty" yaml:"x11_layout,omitempty"`
+	X11Model   string            `json:"x11_model,omitempty" yaml:"x11_model,omitempty"`
+	X11Variant string            `json:"x11_variant,omitempty" yaml:"x11_variant,omitempty"`
+}
+
+// GetLocale returns the locale settings.
+func GetLocale() Locale {
+	locale := Locale{
+		Lang:       os.Getenv("LANG"),
+		Categories: map[string]string{},
+		System:     systemLocale(),
+		Timezone:   timezone(),
+		Keymap:     parseKeyValue(readFile("/etc/vconsole.conf"))["KEYMAP"],
+	}
+	for _, v := range localeCategories {
+		locale.Categories[v] = localeCategory(v, os.Getenv)
+	}
+
+	x11 := x11Keyboard(readFile("/etc/X11/xorg.conf.d/00-keyboard.conf"))
+	locale.X11Layout = x11["XkbLayout"]
+	locale.X11Model = x11["XkbModel"]
+	locale.X11Variant = x11["XkbVariant"]
+	if emptyStr(locale.X11Layout) {
+		// Debian keeps the X11 layout in /etc/default/keyboard.
+		keyboard := parseKeyValue(readFile("/etc/default/keyboard"))
+		locale.X11Layout = keyboard["XKBLAYOUT"]
+		locale.X11Model = keyboard["XKBMODEL"]
+		locale.X11Variant = keyboard["XKBVARIANT"]
+	}
+	return locale
+}
+
+// localeCategory resolves a category with the POSIX precedence
+// LC_ALL > LC_xxx > LANG, falling back to the "C" locale.
+func localeCategory(category string, getenv func(string) string) string {
+	for _, v := range []string{"LC_ALL", category, "LANG"} {
+		if value := getenv(v); !emptyStr(value) {
+			return value
+		}
+	}
+	return "C"
+}
+
+func systemLocale() string {
+	for _, v := range []string{"/etc/locale.conf", "/etc/default/locale"} {
+		if lang := parseKeyValue(readFile(v))["LANG"]; !emptyStr(lang) {
+			return lang
+		}
+	}
+	return ""
+}
+
+// timezone returns the TZ of this process, or else the system timezone.
+// The /etc/localtime link is read, not resolved, like timedatectl does:
+// resolving it would turn aliases such as US/Pacific into the zone they
+// link to.
+func timezone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); !emptyStr(tz) {
+		return tz
+	}
+
+	if link, err := os.Readlink("/etc/localtime"); err == nil {
+		if tz := zoneFromLink(link); !emptyStr(tz) {
+			return tz
+		}
+	}
+	return strings.TrimSpace(readFile("/etc/timezone"))
+}
+
+// zoneFromLink returns the zone name of an /etc/localtime link target such
+// as "../usr/share/zoneinfo/US/Pacific", or "".
+func zoneFromLink(link string) string {
+	i := strings.Index(link, "zoneinfo/")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimPrefix(link[i+len("zoneinfo/"):], "posix/")
+}
+
+// x11Keyboard returns the Xkb* options of an xorg.conf InputClass section.
+func x11Keyboard(conf string) map[string]string {
+	options := map[string]string{}
+	rep := regexp.MustCompile(`^\s*Option\s+"(Xkb\w+)"\s+"([^"]*)"`)
+	for _, line := range strings.Split(conf, "\n") {
+		if m := rep.FindStringSubmatch(line); m != nil {
+			options[m[1]] = m[2]
+		}
+	}
+	return options
+}
@@ -0,0 +1,308 @@
+//
+// osinfo/logo.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// logoFiles is the built-in logo catalogue. Each file is named after the
+// os-release ID of the distribution and holds "@@" directives:
+//
+//	@@names <distribution names, comma separated>
+//	@@colors <palette, ANSI 256 color numbers>
+//	@@ids <additional os-release IDs>
+//	@@large
+//	<art>
+//	@@small
+//	<art>
+//
+// Art lines switch colors with ${c1} .. ${c6}, which index the palette.
+//
+//go:embed logos/*.txt
+var logoFiles embed.FS
+
+// fallbackLogo is used when no logo matches the distribution.
+const fallbackLogo = "linux"
+
+// Logo is the ASCII art of a distribution.
+type Logo struct {
+	// ID is the os-release ID the logo is stored under.
+	ID string
+	// Names are the distribution names (as in OsInfo.Distro) that use the logo.
+	Names []string
+	// IDs are the os-release IDs that use the logo, ID included.
+	IDs []string
+	// Colors is the palette referred to by ${c1} .. ${c6} in the art.
+	Colors []int
+	// Large and Small are the art lines, including the color placeholders.
+	Large []string
+	Small []string
+}
+
+var (
+	logoOnce    sync.Once
+	logoCatalog []Logo
+)
+
+var (
+	colorPlaceholder = regexp.MustCompile(`\$\{c([0-9])\}`)
+	ansiEscape       = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+)
+
+// Logos returns the built-in logo catalogue sorted by ID.
+func Logos() []Logo {
+	logoOnce.Do(func() {
+		entries, err := logoFiles.ReadDir("logos")
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			data, err := logoFiles.ReadFile(path.Join("logos", e.Name()))
+			if err != nil {
+				continue
+			}
+			logoCatalog = append(logoCatalog, parseLogo(strings.TrimSuffix(e.Name(), ".txt"), string(data)))
+		}
+		sort.Slice(logoCatalog, func(i, j int) bool { return logoCatalog[i].ID < logoCatalog[j].ID })
+	})
+	return logoCatalog
+}
+
+func parseLogo(id string, contents string) Logo {
+	logo := Logo{ID: id, IDs: []string{id}}
+	var art *[]string
+	for _, line := range strings.Split(strings.TrimRight(contents, "\n"), "\n") {
+		if !strings.HasPrefix(line, "@@") {
+			if art != nil {
+				*art = append(*art, line)
+			}
+			continue
+		}
+
+		directive, value := line[2:], ""
+		if i := strings.Index(directive, " "); i >= 0 {
+			directive, value = directive[:i], strings.TrimSpace(directive[i+1:])
+		}
+		switch directive {
+		case "names":
+			for _, name := range strings.Split(value, ",") {
+				logo.Names = append(logo.Names, strings.TrimSpace(name))
+			}
+		case "ids":
+			logo.IDs = append(logo.IDs, strings.Fields(value)...)
+		case "colors":
+			for _, v := range strings.Fields(value) {
+				if n, err := strconv.Atoi(v); err == nil {
+					logo.Colors = append(logo.Colors, n)
+				}
+			}
+		case "large":
+			art = &logo.Large
+		case "small":
+			art = &logo.Small
+		}
+	}
+	return logo
+}
+
+// LookupLogo returns the logo for an os-release ID ("ubuntu") or a
+// distribution name as detected by Get ("Bedrock Linux 0.7.28"). Names are
+// matched case-insensitively on whole words, and the longest match wins.
+func LookupLogo(name string) (Logo, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return Logo{}, false
+	}
+
+	best, bestLen := -1, 0
+	catalog := Logos()
+	for i, logo := range catalog {
+		for _, id := range logo.IDs {
+			if id == name {
+				return logo, true
+			}
+		}
+		for _, n := range logo.Names {
+			n = strings.ToLower(n)
+			if (name == n || strings.HasPrefix(name, n+" ")) && len(n) > bestLen {
+				best, bestLen = i, len(n)
+			}
+		}
+	}
+	if best < 0 {
+		return Logo{}, false
+	}
+	return catalog[best], true
+}
+
+// LogoFor returns the logo of the machine described by info. The detected
+// distribution name is tried first, then the os-release ID and ID_LIKE, and
+// the Linux penguin is returned when nothing matches.
+func LogoFor(info OsInfo) Logo {
+	candidates := []string{info.Distro}
+	if info.Mac != nil {
+		candidates = append(candidates, "macos")
+	}
+	if info.Android != nil {
+		candidates = append(candidates, "android")
+	}
+	release := osRelease()
+	candidates = append(candidates, release["ID"])
+	candidates = append(candidates, strings.Fields(release["ID_LIKE"])...)
+	candidates = append(candidates, info.Os)
+
+	for _, c := range candidates {
+		if logo, ok := LookupLogo(c); ok {
+			return logo
+		}
+	}
+	logo, _ := LookupLogo(fallbackLogo)
+	return logo
+}
+
+// Color returns the ANSI escape sequence that selects palette color n
+// (1 for ${c1}). Indexes past the palette use its last color.
+func (l Logo) Color(n int) string {
+	if len(l.Colors) == 0 {
+		return ""
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > len(l.Colors) {
+		n = len(l.Colors)
+	}
+	return ansiColor(l.Colors[n-1])
+}
+
+func ansiColor(n int) string {
+	switch {
+	case n < 8:
+		return fmt.Sprintf("\x1b[3%dm", n)
+	case n < 16:
+		return fmt.Sprintf("\x1b[9%dm", n-8)
+	default:
+		return fmt.Sprintf("\x1b[38;5;%dm", n)
+	}
+}
+
+// Art returns the small or large art with the placeholders replaced by
+// ANSI colors, or removed when color is false. The large art is returned
+// when the small one is missing, and vice versa.
+func (l Logo) Art(small bool, color bool) []string {
+	lines := l.Large
+	if (small && len(l.Small) > 0) || len(lines) == 0 {
+		lines = l.Small
+	}
+
+	art := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = colorPlaceholder.ReplaceAllStringFunc(line, func(p string) string {
+			if !color {
+				return ""
+			}
+			n, _ := strconv.Atoi(colorPlaceholder.FindStringSubmatch(p)[1])
+			return l.Color(n)
+		})
+		if color {
+			line += colorReset
+		}
+		art = append(art, line)
+	}
+	return art
+}
+
+const colorReset = "\x1b[0m"
+
+// Render places the info lines to the right of the art, three columns past
+// its widest line. The info lines may contain ANSI escape sequences of their
+// own.
+func (l Logo) Render(info []string, small bool, color bool) string {
+	art := l.Art(small, color)
+	width := 0
+	for _, line := range art {
+		if w := DisplayWidth(line); w > width {
+			width = w
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(art) || i < len(info); i++ {
+		line := ""
+		if i < len(art) {
+			line = art[i]
+		}
+		if i < len(info) {
+			line += strings.Repeat(" ", width-DisplayWidth(line)+3) + info[i]
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return b.String()
+}
+
+// DisplayWidth returns the number of terminal columns s occupies. ANSI
+// escape sequences and combining marks take no columns, and East Asian wide
+// characters take two.
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range ansiEscape.ReplaceAllString(s, "") {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// wideRanges are the East Asian Wide and Fullwidth blocks, plus the emoji
+// blocks that terminals draw in two columns.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK Radicals .. CJK Symbols and Punctuation
+	{0x3041, 0x33FF},   // Hiragana .. CJK Compatibility
+	{0x3400, 0x4DBF},   // CJK Unified Ideographs Extension A
+	{0x4E00, 0x9FFF},   // CJK Unified Ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul Syllables
+	{0xF900, 0xFAFF},   // CJK Compatibility Ideographs
+	{0xFE30, 0xFE4F},   // CJK Compatibility Forms
+	{0xFF00, 0xFF60},   // Fullwidth Forms
+	{0xFFE0, 0xFFE6},   // Fullwidth Signs
+	{0x1F300, 0x1F64F}, // Miscellaneous Symbols and Pictographs, Emoticons
+	{0x1F900, 0x1F9FF}, // Supplemental Symbols and Pictographs
+	{0x20000, 0x3FFFD}, // CJK Unified Ideographs Extension B ..
+}
+
+func runeWidth(r rune) int {
+	if r == utf8.RuneError || unicode.IsControl(r) ||
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, rng := range wideRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return 2
+		}
+	}
+	return 1
+}
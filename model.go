@@ -17,7 +17,6 @@
 package osinfo
 
 import (
-	"os/exec"
 	"regexp"
 	"strings"
 )
@@ -183,7 +182,7 @@ func getiPhoneModelName(kernelArch string) string {
 }
 
 func getBsdOrMinixModelName() string {
-	out, err := exec.Command("sysctl", "-n", "hw.vendor", "hw.product").Output()
+	out, err := runCmd("sysctl", "-n", "hw.vendor", "hw.product")
 	if err != nil {
 		return "BSD(or Minix)"
 	}
@@ -191,7 +190,7 @@ func getBsdOrMinixModelName() string {
 }
 
 func getWindowsModelName() string {
-	out, err := exec.Command("wmic", "computersystem", "get", "manufacturer,model").Output()
+	out, err := runCmd("wmic", "computersystem", "get", "manufacturer,model")
 	if err != nil {
 		return "Windows"
 	}
@@ -201,7 +200,7 @@ func getWindowsModelName() string {
 }
 
 func getSolarisModelName() string {
-	out, err := exec.Command("prtconf", "-b").Output()
+	out, err := runCmd("prtconf", "-b")
 	if err != nil {
 		return "Solaris"
 	}
@@ -216,7 +215,7 @@ func getSolarisModelName() string {
 }
 
 func getAixModelName() string {
-	out, err := exec.Command("/usr/bin/uname", "-M").Output()
+	out, err := runCmd("/usr/bin/uname", "-M")
 	if err != nil {
 		return "AIX"
 	}
@@ -224,7 +223,7 @@ func getAixModelName() string {
 }
 
 func getFreeMintModelName() string {
-	out, err := exec.Command("sysctl", "-n", "hw.model").Output()
+	out, err := runCmd("sysctl", "-n", "hw.model")
 	if err != nil {
 		return "FreeMiNT"
 	}
@@ -232,22 +231,12 @@ func getFreeMintModelName() string {
 }
 
 func androidModelName() string {
-	modelName := ""
-	brand, brandErr := exec.Command("getprop", "ro.product.brand").Output()
-	model, modelErr := exec.Command("getprop", "ro.product.model").Output()
-
-	if brandErr != nil && modelErr == nil {
-		modelName = string(model)
-	} else if brandErr == nil && modelErr != nil {
-		modelName = string(brand)
-	} else if brandErr == nil && modelErr == nil {
-		modelName = string(brand) + " " + string(model)
-	}
-	return modelName
+	android := localAndroid()
+	return strings.TrimSpace(android.Brand + " " + android.Model)
 }
 
 func hackintoshModelName() string {
-	out, err := exec.Command("sysctl", "-n", "hw.model").Output()
+	out, err := runCmd("sysctl", "-n", "hw.model")
 	if err != nil {
 		return "Hackintosh"
 	}
@@ -255,7 +244,7 @@ func hackintoshModelName() string {
 }
 
 func macModelName() string {
-	out, err := exec.Command("sysctl", "-n", "hw.model").Output()
+	out, err := runCmd("sysctl", "-n", "hw.model")
 	if err != nil {
 		return "Macintosh"
 	}
@@ -268,8 +257,8 @@ func hasBoardInfoFile() bool {
 }
 
 func hasProductInfoFile() bool {
-	return isFile("/sys/devices/virtual/dmi/id/board_vendor") ||
-		isFile("/sys/devices/virtual/dmi/id/board_name")
+	return isFile("/sys/devices/virtual/dmi/id/product_name") ||
+		isFile("/sys/devices/virtual/dmi/id/product_version")
 }
 
 func hasFirmwareInfoFile() bool {
@@ -291,7 +280,7 @@ func productInfo() string {
 }
 
 func firmwareInfo() string {
-	return readFile("/sys/firmware/devicetree/base/model")
+	return deviceTreeString(readFile("/sys/firmware/devicetree/base/model"))
 }
 
 func sysinfoModelFile() string {
@@ -299,7 +288,7 @@ func sysinfoModelFile() string {
 }
 
 func isHackintosh() bool {
-	out, err := exec.Command("kextstat").Output()
+	out, err := runCmd("kextstat")
 	if err != nil {
 		return false
 	}
@@ -0,0 +1,252 @@
+//
+// osinfo/options.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Options controls what GetWithOptions collects. The zero value collects
+// everything, like Get. Options is also the format of the osinfo config
+// file (see LoadOptions):
+//
+//	fields = ["distro", "kernel", "uptime", "sensors"]
+//	include_identifiers = false
+//	timeout = "2s"
+//	disabled_probes = ["lsb_release", "xrandr"]
+//
+//	[labels]
+//	distro = "Distribution"
+//
+//	[units]
+//	temperature = "fahrenheit"
+type Options struct {
+	// Fields are the OsInfo sections to collect, named by their JSON keys.
+	// "os" and "kernel" are always collected. Empty means all sections.
+	Fields []string `json:"fields,omitempty" yaml:"fields,omitempty" toml:"fields"`
+	// Labels rename the lines of text output, keyed by field name.
+	// The library does not use them.
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty" toml:"labels"`
+	// IncludeIdentifiers keeps serial numbers and UUIDs (DMI, displays)
+	// and collects the host name and machine ID, which are left out by
+	// default.
+	IncludeIdentifiers bool `json:"include_identifiers,omitempty" yaml:"include_identifiers,omitempty" toml:"include_identifiers"`
+	// Timeout kills every spawned command that runs longer. Zero means
+	// no timeout.
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty" toml:"timeout"`
+	// Units selects the units of the collected values.
+	Units Units `json:"units,omitempty" yaml:"units,omitempty" toml:"units"`
+	// DisabledProbes are commands that are never spawned, e.g. "lsb_release".
+	// Information that only the command can give is left empty.
+	DisabledProbes []string `json:"disabled_probes,omitempty" yaml:"disabled_probes,omitempty" toml:"disabled_probes"`
+}
+
+// Units selects the units of the collected values.
+type Units struct {
+	// Temperature is "celsius" (default) or "fahrenheit".
+	Temperature string `json:"temperature,omitempty" yaml:"temperature,omitempty" toml:"temperature"`
+}
+
+// probes is the command policy of the running collection, and the
+// commands it has run so far. memo is nil outside of a collection.
+var probes = struct {
+	sync.RWMutex
+	timeout  time.Duration
+	disabled map[string]bool
+	memo     map[string]*memoEntry
+}{}
+
+// memoEntry is the result of a command run, or of a file parsed (value),
+// during a collection.
+type memoEntry struct {
+	once  sync.Once
+	out   []byte
+	err   error
+	value interface{}
+}
+
+// getMu serializes GetWithOptions, which sets the command policy for the
+// whole collection.
+var getMu sync.Mutex
+
+func setProbes(opts Options, memo bool) {
+	probes.Lock()
+	defer probes.Unlock()
+	probes.memo = nil
+	if memo {
+		probes.memo = map[string]*memoEntry{}
+	}
+	probes.timeout = opts.Timeout
+	probes.disabled = map[string]bool{}
+	for _, p := range opts.DisabledProbes {
+		probes.disabled[p] = true
+	}
+}
+
+// memoized returns the memo entry of key, or nil outside of a collection.
+func memoized(key string) *memoEntry {
+	probes.Lock()
+	defer probes.Unlock()
+	if probes.memo == nil {
+		return nil
+	}
+	entry, ok := probes.memo[key]
+	if !ok {
+		entry = &memoEntry{}
+		probes.memo[key] = entry
+	}
+	return entry
+}
+
+func probeTimeout() time.Duration {
+	probes.RLock()
+	defer probes.RUnlock()
+	return probes.timeout
+}
+
+// wants reports whether the section named field is collected.
+func (o Options) wants(field string) bool {
+	if len(o.Fields) == 0 || field == "os" || field == "kernel" {
+		return true
+	}
+	for _, f := range o.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the field names and units.
+func (o Options) Validate() error {
+	for _, f := range o.Fields {
+		if f != "os" && findCollector(f) == nil {
+			return fmt.Errorf("unknown field %q", f)
+		}
+	}
+	switch strings.ToLower(o.Units.Temperature) {
+	case "", "celsius", "c", "fahrenheit", "f":
+	default:
+		return fmt.Errorf("unknown temperature unit %q", o.Units.Temperature)
+	}
+	if o.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+// ConfigPath returns the path of the TOML config file,
+// $XDG_CONFIG_HOME/osinfo/config.toml ($HOME/.config when XDG_CONFIG_HOME
+// is not set).
+func ConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "osinfo", "config.toml")
+}
+
+// LoadOptions reads a config file: YAML when the name ends with .yaml or
+// .yml, otherwise TOML. Unknown keys are errors.
+func LoadOptions(path string) (Options, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Options{}, err
+	}
+
+	opts := Options{}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+			return Options{}, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		meta, err := toml.Decode(string(data), &opts)
+		if err != nil {
+			return Options{}, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return Options{}, fmt.Errorf("%s: unknown key %q", path, undecoded[0].String())
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return Options{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return opts, nil
+}
+
+// LoadDefaultOptions loads config.toml, config.yaml or config.yml from the
+// directory of ConfigPath, whichever exists first. Without a config file,
+// it returns the zero Options.
+func LoadDefaultOptions() (Options, error) {
+	path := ConfigPath()
+	if path == "" {
+		return Options{}, nil
+	}
+	base := strings.TrimSuffix(path, ".toml")
+	for _, p := range []string{path, base + ".yaml", base + ".yml"} {
+		if isFile(p) {
+			return LoadOptions(p)
+		}
+	}
+	return Options{}, nil
+}
+
+// sensorsInUnits converts the temperatures, which are read in Celsius.
+func sensorsInUnits(readings []SensorReading, units Units) []SensorReading {
+	switch strings.ToLower(units.Temperature) {
+	case "fahrenheit", "f":
+		for i, r := range readings {
+			if r.Kind != Temperature {
+				continue
+			}
+			readings[i].Value = r.Value*9/5 + 32
+			if r.Critical != 0 {
+				readings[i].Critical = r.Critical*9/5 + 32
+			}
+			readings[i].Unit = "°F"
+		}
+	}
+	return readings
+}
+
+// redactDisplays removes the serial numbers that GetDisplays reads from
+// EDID. DMI identifiers are left out by GetDMI itself.
+func redactDisplays(displays []Display) {
+	for i := range displays {
+		displays[i].Serial = ""
+	}
+}
@@ -18,11 +18,10 @@ package osinfo
 
 import "regexp"
 
-func operatingSystem(kernelName string) string {
+func operatingSystem(kernelName string, mac MacInfo) string {
 	var os string = "Unknown"
 	switch kernelName {
 	case "Darwin":
-		mac := getMacProductInfo()
 		os = mac.Name
 	case "SunOS":
 		os = "Solaris"
@@ -16,6 +16,8 @@
 // limitations under the License.
 package osinfo
 
+import "sync"
+
 type utsname struct {
 	sys     string
 	node    string
@@ -25,50 +27,226 @@ type utsname struct {
 	domain  string
 }
 
-type macProductInfo struct {
-	Name     string
-	Ver      string
-	BuildVer string
+// MacInfo is the product information reported by sw_vers.
+type MacInfo struct {
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Ver      string `json:"version,omitempty" yaml:"version,omitempty"`
+	BuildVer string `json:"build_version,omitempty" yaml:"build_version,omitempty"`
 }
 
 type Kernel struct {
-	Name string
-	Ver  string
-	Arch string
+	Name     string         `json:"name" yaml:"name"`
+	Ver      string         `json:"version" yaml:"version"`
+	Arch     string         `json:"arch" yaml:"arch"`
+	Tainted  []string       `json:"tainted,omitempty" yaml:"tainted,omitempty"`
+	Modules  []KernelModule `json:"modules,omitempty" yaml:"modules,omitempty"`
+	PageSize int            `json:"page_size" yaml:"page_size"`
+	Flavour  string         `json:"flavour,omitempty" yaml:"flavour,omitempty"`
 }
 
+// SchemaVersion is the version of the OsInfo JSON/YAML encoding described
+// by schema/osinfo.schema.json. It is incremented on incompatible changes.
+const SchemaVersion = 1
+
 type OsInfo struct {
-	Os     string
-	Distro string
-	Model  string
-	Kernel Kernel
-	Uptime string
-	Shell  string
-	Mac    macProductInfo
+	SchemaVersion  int             `json:"schema_version" yaml:"schema_version"`
+	Os             string          `json:"os" yaml:"os"`
+	Distro         string          `json:"distro" yaml:"distro"`
+	DistroID       string          `json:"distro_id,omitempty" yaml:"distro_id,omitempty"`
+	DistroName     string          `json:"distro_name,omitempty" yaml:"distro_name,omitempty"`
+	VersionID      string          `json:"version_id,omitempty" yaml:"version_id,omitempty"`
+	Model          string          `json:"model" yaml:"model"`
+	Kernel         Kernel          `json:"kernel" yaml:"kernel"`
+	Uptime         string          `json:"uptime" yaml:"uptime"`
+	UptimeSeconds  int64           `json:"uptime_seconds,omitempty" yaml:"uptime_seconds,omitempty"`
+	BootTime       int64           `json:"boot_time,omitempty" yaml:"boot_time,omitempty"`
+	Shell          string          `json:"shell" yaml:"shell"`
+	Mac            *MacInfo        `json:"mac,omitempty" yaml:"mac,omitempty"`
+	DMI            *DMI            `json:"dmi,omitempty" yaml:"dmi,omitempty"`
+	Board          *Board          `json:"board,omitempty" yaml:"board,omitempty"`
+	Power          Power           `json:"power,omitempty" yaml:"power,omitempty"`
+	Sensors        []SensorReading `json:"sensors,omitempty" yaml:"sensors,omitempty"`
+	Displays       []Display       `json:"displays,omitempty" yaml:"displays,omitempty"`
+	Locale         Locale          `json:"locale" yaml:"locale"`
+	Init           Init            `json:"init" yaml:"init"`
+	Security       Security        `json:"security" yaml:"security"`
+	Firmware       Firmware        `json:"firmware" yaml:"firmware"`
+	WSL            *WSL            `json:"wsl,omitempty" yaml:"wsl,omitempty"`
+	ChromeOS       *ChromeOS       `json:"chromeos,omitempty" yaml:"chromeos,omitempty"`
+	Android        *Android        `json:"android,omitempty" yaml:"android,omitempty"`
+	Image          *Image          `json:"image,omitempty" yaml:"image,omitempty"`
+	Support        *SupportStatus  `json:"support,omitempty" yaml:"support,omitempty"`
+	Virtualization string          `json:"virtualization,omitempty" yaml:"virtualization,omitempty"`
+	Container      *Container      `json:"container,omitempty" yaml:"container,omitempty"`
+	Cloud          *Cloud          `json:"cloud,omitempty" yaml:"cloud,omitempty"`
+	Hostname       string          `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	MachineID      string          `json:"machine_id,omitempty" yaml:"machine_id,omitempty"`
+}
+
+// Get collects everything, or what the options select:
+//
+//	info := osinfo.Get(osinfo.WithFields("distro", "uptime"))
+func Get(opts ...Option) OsInfo {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return GetWithOptions(o)
+}
+
+// Option configures Get.
+type Option func(*Options)
+
+// WithFields collects only the named OsInfo sections (JSON keys), plus
+// "os" and "kernel".
+func WithFields(fields ...string) Option {
+	return func(o *Options) {
+		o.Fields = append(o.Fields, fields...)
+	}
+}
+
+// WithOptions replaces the options set so far with opts.
+func WithOptions(opts Options) Option {
+	return func(o *Options) {
+		*o = opts
+	}
+}
+
+// GetWithOptions collects the sections selected by opts. Sections that are
+// not selected are left at their zero value.
+func GetWithOptions(opts Options) OsInfo {
+	info := OsInfo{}
+	collect(&info, &collectEnv{}, opts, true, false)
+	return info
+}
+
+// collect runs the selected collectors, each in its own goroutine when
+// concurrent is true, and only the dynamic ones when dynamicOnly is true.
+// The output of a command is shared by the collectors that run it during
+// one collection. uname and sw_vers are read into env on its first use
+// only, so that a Collector does not run them on every Refresh.
+func collect(info *OsInfo, env *collectEnv, opts Options, concurrent bool, dynamicOnly bool) {
+	getMu.Lock()
+	defer getMu.Unlock()
+	setProbes(opts, true)
+	defer setProbes(Options{}, false)
+
+	if !env.ready {
+		env.uts = uts()
+		env.mac = getMacProductInfo()
+		env.os = operatingSystem(env.uts.sys, env.mac)
+		env.ready = true
+	}
+	env.opts = opts
+	info.SchemaVersion = SchemaVersion
+	info.Os = env.os
+
+	// Every collector writes its own OsInfo field only.
+	var wg sync.WaitGroup
+	for _, c := range collectors {
+		if !opts.wants(c.name) || (dynamicOnly && !c.dynamic) {
+			continue
+		}
+		if !concurrent {
+			c.collect(info, *env)
+			continue
+		}
+		wg.Add(1)
+		go func(c collector) {
+			defer wg.Done()
+			c.collect(info, *env)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// collectEnv is what the collectors share. ready is set once uts, os and
+// mac are read.
+type collectEnv struct {
+	ready bool
+	uts   utsname
+	os    string
+	mac   MacInfo
+	opts  Options
+}
+
+// collector fills the OsInfo section whose JSON key is name. Dynamic
+// sections change while a process runs, and are the ones that
+// Collector.Refresh collects again.
+type collector struct {
+	name    string
+	dynamic bool
+	collect func(info *OsInfo, env collectEnv)
+}
+
+var collectors = []collector{
+	// Modules are loaded and the kernel is tainted at run time.
+	{"kernel", true, func(info *OsInfo, env collectEnv) { info.Kernel = getKernel(env.uts) }},
+	{"distro", false, func(info *OsInfo, env collectEnv) {
+		info.Distro = distribution(env.os, env.uts.sys, env.uts.release, env.mac)
+		info.DistroID, info.VersionID, info.DistroName = releaseID(env.os, env.mac)
+	}},
+	{"model", false, func(info *OsInfo, env collectEnv) { info.Model = model(env.os, env.uts.machine) }},
+	{"uptime", true, func(info *OsInfo, env collectEnv) {
+		info.UptimeSeconds = uptimeSeconds(env.os)
+		info.Uptime = formatUptime(info.UptimeSeconds)
+		info.BootTime = bootTime(info.UptimeSeconds)
+	}},
+	{"shell", false, func(info *OsInfo, env collectEnv) { info.Shell = getShell() }},
+	{"mac", false, func(info *OsInfo, env collectEnv) { info.Mac = macInfo(env.os, env.mac) }},
+	{"dmi", false, func(info *OsInfo, env collectEnv) { info.DMI = GetDMI(env.opts.IncludeIdentifiers) }},
+	{"board", false, func(info *OsInfo, env collectEnv) { info.Board = GetBoard() }},
+	{"power", true, func(info *OsInfo, env collectEnv) { info.Power = GetPower() }},
+	{"sensors", true, func(info *OsInfo, env collectEnv) {
+		info.Sensors = sensorsInUnits(GetSensors(), env.opts.Units)
+	}},
+	{"displays", true, func(info *OsInfo, env collectEnv) {
+		info.Displays = GetDisplays(true)
+		if !env.opts.IncludeIdentifiers {
+			redactDisplays(info.Displays)
+		}
+	}},
+	{"locale", false, func(info *OsInfo, env collectEnv) { info.Locale = GetLocale() }},
+	{"init", false, func(info *OsInfo, env collectEnv) { info.Init = GetInit() }},
+	{"security", false, func(info *OsInfo, env collectEnv) { info.Security = GetSecurity() }},
+	{"firmware", false, func(info *OsInfo, env collectEnv) { info.Firmware = GetFirmware() }},
+	{"wsl", false, func(info *OsInfo, env collectEnv) { info.WSL = GetWSL(env.uts.release) }},
+	{"chromeos", false, func(info *OsInfo, env collectEnv) { info.ChromeOS = GetChromeOS() }},
+	{"android", false, func(info *OsInfo, env collectEnv) { info.Android = GetAndroid() }},
+	{"image", true, func(info *OsInfo, env collectEnv) { info.Image = GetImage() }},
+	{"support", true, func(info *OsInfo, env collectEnv) { info.Support = supportStatus(env.os, env.mac) }},
+	{"virtualization", false, func(info *OsInfo, env collectEnv) { info.Virtualization = GetVirtualization() }},
+	{"container", false, func(info *OsInfo, env collectEnv) { info.Container = GetContainer() }},
+	{"cloud", false, func(info *OsInfo, env collectEnv) { info.Cloud = GetCloud() }},
+	// The host name and machine ID identify a single machine.
+	{"hostname", false, func(info *OsInfo, env collectEnv) {
+		if env.opts.IncludeIdentifiers {
+			info.Hostname = GetHostname()
+		}
+	}},
+	{"machine_id", false, func(info *OsInfo, env collectEnv) {
+		if env.opts.IncludeIdentifiers {
+			info.MachineID = GetMachineID()
+		}
+	}},
+}
+
+func findCollector(name string) *collector {
+	for i := range collectors {
+		if collectors[i].name == name {
+			return &collectors[i]
+		}
+	}
+	return nil
 }
 
-func Get() OsInfo {
-	utsname := uts()
-	os := operatingSystem(utsname.sys)
-
-	osinfo := OsInfo{
-		Os: os,
-		Distro: distribution(
-			os,
-			utsname.sys,
-			utsname.release,
-			getMacProductInfo()),
-		Model: model(os, utsname.machine),
-		Kernel: Kernel{
-			Name: utsname.sys,
-			Ver:  utsname.release,
-			Arch: utsname.machine,
-		},
-		Uptime: getUptime(os),
-		Shell:  getShell(),
-		Mac:    getMacProductInfo(),
+// macInfo returns the sw_vers information only on Apple systems.
+func macInfo(os string, mac MacInfo) *MacInfo {
+	switch os {
+	case "Mac OS X", "macOS", "iPhone OS":
+		return &mac
 	}
-	return osinfo
+	return nil
 }
 
 func utsToString(f [65]int8) string {
@@ -19,40 +19,50 @@
 package osinfo
 
 import (
+	"strings"
 	"syscall"
 )
 
 func uts() utsname {
-	u := unix.Utsname{}
-	err := unix.Uname(&u)
+	sys, err := syscall.Sysctl("kern.ostype")
 	if err != nil {
 		return utsname{}
 	}
+	node, _ := syscall.Sysctl("kern.hostname")
+	release, _ := syscall.Sysctl("kern.osrelease")
+	version, _ := syscall.Sysctl("kern.version")
+	machine, _ := syscall.Sysctl("hw.machine")
 
 	uname := utsname{
-		sys:     utsToString(u.Sysname),
-		node:    utsToString(u.Nodename),
-		release: utsToString(u.Release),
-		version: utsToString(u.Version),
-		machine: utsToString(u.Machine),
-		domain:  utsToString(u.Domainname),
+		sys:     sys,
+		node:    node,
+		release: release,
+		version: version,
+		machine: machine,
 	}
 	return uname
 }
 
-func getMacProductInfo() macProductInfo {
-	result, err := exec.Command("sw_vers").Output()
+func getMacProductInfo() MacInfo {
+	result, err := runCmd("sw_vers")
 	if err != nil {
-		return macProductInfo{}
+		return MacInfo{}
 	}
-	productInfo := strings.Split(string(result), "\n")
-	nameLine:=strings.Split(productInfo[0], ":")
-	verLine:=strings.Split(productInfo[1], ":")
-	buildVerLine:=strings.Split(productInfo[2], ":")
 
-	return macProductInfo{
-		name: strings.TrimSpace(nameLine[1])
-		version:strings.TrimSpace(verLine[1])
-		buildVer:strings.TrimSpace(buildVerLine[1])
+	info := MacInfo{}
+	for _, line := range strings.Split(string(result), "\n") {
+		elem := strings.SplitN(line, ":", 2)
+		if len(elem) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(elem[0]) {
+		case "ProductName":
+			info.Name = strings.TrimSpace(elem[1])
+		case "ProductVersion":
+			info.Ver = strings.TrimSpace(elem[1])
+		case "BuildVersion":
+			info.BuildVer = strings.TrimSpace(elem[1])
+		}
 	}
+	return info
 }
@@ -40,9 +40,6 @@ func uts() utsname {
 	return uname
 }
 
-func getMacProductInfo() macProductInfo {
-	return macProductInfo{
-		Name:     "This is not mac",
-		Ver:      "No version information",
-		BuildVer: "No build information"}
+func getMacProductInfo() MacInfo {
+	return MacInfo{}
 }
@@ -16,8 +16,1265 @@
 // limitations under the License.
 package osinfo
 
-import "testing"
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestGet(t *testing.T) {
 	Get()
 }
+
+func BenchmarkGet(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Get()
+	}
+}
+
+func BenchmarkGetSerial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		collect(&OsInfo{}, &collectEnv{}, Options{}, false, false)
+	}
+}
+
+func BenchmarkGetFields(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Get(WithFields("distro", "uptime"))
+	}
+}
+
+func TestChassisType(t *testing.T) {
+	tests := map[string]string{
+		"3":  "Desktop",
+		"10": "Laptop",
+		"17": "Server",
+		"30": "Tablet",
+		"2":  "Unknown",
+		"":   "",
+	}
+	for code, want := range tests {
+		if got := chassisType(code); got != want {
+			t.Errorf("chassisType(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestDecodePiRevision(t *testing.T) {
+	tests := []struct {
+		code string
+		want PiRevision
+	}{
+		{"a02082", PiRevision{Code: "a02082", Model: "Raspberry Pi 3B", PCBRevision: "1.2",
+			Memory: "1GB", Manufacturer: "Sony UK", Processor: "BCM2837"}},
+		{"c03111", PiRevision{Code: "c03111", Model: "Raspberry Pi 4B", PCBRevision: "1.1",
+			Memory: "4GB", Manufacturer: "Sony UK", Processor: "BCM2711"}},
+		{"000e", PiRevision{Code: "000e", Model: "Raspberry Pi B", PCBRevision: "2.0",
+			Memory: "512MB", Manufacturer: "Sony UK", Processor: "BCM2835"}},
+	}
+	for _, tt := range tests {
+		got := decodePiRevision(tt.code)
+		if got == nil || *got != tt.want {
+			t.Errorf("decodePiRevision(%q) = %+v, want %+v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestOnAC(t *testing.T) {
+	tests := []struct {
+		name  string
+		power Power
+		want  bool
+	}{
+		{"no power supplies", Power{}, true},
+		{"adapter online", Power{{Type: "Mains", Online: true}, {Type: "Battery", Status: "Discharging"}}, true},
+		{"adapter offline", Power{{Type: "Mains"}, {Type: "Battery", Status: "Discharging"}}, false},
+		{"battery charging", Power{{Type: "Battery", Status: "Charging"}}, true},
+		{"one battery discharging", Power{{Type: "Battery", Status: "Full"}, {Type: "Battery", Status: "Discharging"}}, false},
+		{"desktop with UPS", Power{{Type: "UPS", Online: true}}, true},
+		{"desktop with a wireless mouse", Power{{Type: "Battery", Status: "Discharging", Scope: "Device"}}, true},
+		{"laptop with a wireless mouse", Power{{Type: "Battery", Status: "Discharging"}, {Type: "Battery", Status: "Full", Scope: "Device"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.power.OnAC(); got != tt.want {
+			t.Errorf("%s: OnAC() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPowerSupply(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		// energy_* in µWh
+		"BAT0/type":               "Battery\n",
+		"BAT0/status":             "Discharging\n",
+		"BAT0/capacity":           "80\n",
+		"BAT0/energy_now":         "40000000\n",
+		"BAT0/energy_full":        "50000000\n",
+		"BAT0/energy_full_design": "57000000\n",
+		// charge_* in µAh, converted with voltage_min_design in µV
+		"BAT1/type":               "Battery\n",
+		"BAT1/charge_now":         "2000000\n",
+		"BAT1/charge_full":        "4000000\n",
+		"BAT1/charge_full_design": "5000000\n",
+		"BAT1/voltage_min_design": "11400000\n",
+		"AC/type":                 "Mains\n",
+		"AC/online":               "1\n",
+		// a wireless mouse
+		"hidpp_battery_0/type":     "Battery\n",
+		"hidpp_battery_0/status":   "Discharging\n",
+		"hidpp_battery_0/scope":    "Device\n",
+		"hidpp_battery_0/capacity": "40\n",
+	})
+
+	approx := func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 }
+	bat0 := powerSupply(filepath.Join(root, "BAT0"))
+	if bat0.Status != "Discharging" || bat0.Capacity == nil || *bat0.Capacity != 80 ||
+		!approx(bat0.EnergyNow, 40) || !approx(bat0.EnergyFull, 50) || !approx(bat0.EnergyFullDesign, 57) ||
+		!approx(bat0.Health, 50.0/57) {
+		t.Errorf("powerSupply(BAT0) = %+v", bat0)
+	}
+	bat1 := powerSupply(filepath.Join(root, "BAT1"))
+	if bat1.Status != "Unknown" || !approx(bat1.EnergyNow, 22.8) || !approx(bat1.EnergyFull, 45.6) ||
+		!approx(bat1.EnergyFullDesign, 57) || !approx(bat1.Health, 0.8) {
+		t.Errorf("powerSupply(BAT1) = %+v", bat1)
+	}
+	if ac := powerSupply(filepath.Join(root, "AC")); ac.Name != "AC" || !ac.Online || ac.Status != "" || ac.Capacity != nil {
+		t.Errorf("powerSupply(AC) = %+v", ac)
+	}
+
+	// A desktop whose only battery is in a wireless mouse is on AC.
+	mouse := powerSupply(filepath.Join(root, "hidpp_battery_0"))
+	if mouse.Scope != "Device" {
+		t.Errorf("powerSupply(hidpp_battery_0) = %+v", mouse)
+	}
+	if desktop := (Power{mouse}); !desktop.OnAC() || len(desktop.Batteries()) != 0 {
+		t.Errorf("Power{mouse}: OnAC() = %v, Batteries() = %+v", desktop.OnAC(), desktop.Batteries())
+	}
+	if laptop := (Power{bat0, mouse}); len(laptop.Batteries()) != 1 || laptop.Batteries()[0].Name != "BAT0" {
+		t.Errorf("Power{BAT0, mouse}.Batteries() = %+v", laptop.Batteries())
+	}
+}
+
+func TestHwmonValue(t *testing.T) {
+	tests := []struct {
+		kind SensorKind
+		raw  int
+		want float64
+	}{
+		{Temperature, 45500, 45.5},
+		{Temperature, -12000, -12},
+		{Voltage, 1224, 1.224},
+		{Fan, 1200, 1200},
+	}
+	for _, tt := range tests {
+		if got := hwmonValue(tt.kind, tt.raw); got != tt.want {
+			t.Errorf("hwmonValue(%s, %d) = %v, want %v", tt.kind, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestHwmonInputs(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"hwmon0/temp1_input":      "45500\n",
+		"hwmon0/temp1_label":      "Tctl\n",
+		"hwmon0/temp1_crit":       "95000\n",
+		"hwmon0/temp2_input":      "38000\n",
+		"hwmon0/fan1_input":       "1200\n",
+		"hwmon0/fan2_input":       "0\n",
+		"zone0/temp":              "51000\n",
+		"zone0/trip_point_0_type": "passive\n",
+		"zone0/trip_point_0_temp": "80000\n",
+		"zone0/trip_point_1_type": "critical\n",
+		"zone0/trip_point_1_temp": "105000\n",
+	})
+
+	chip := filepath.Join(root, "hwmon0")
+	want := []SensorReading{
+		{Chip: "k10temp", Label: "Tctl", Kind: Temperature, Value: 45.5, Unit: "°C", Critical: 95},
+		{Chip: "k10temp", Label: "temp2", Kind: Temperature, Value: 38, Unit: "°C"},
+	}
+	if got := hwmonInputs(chip, "k10temp", "temp", Temperature, "°C"); !reflect.DeepEqual(got, want) {
+		t.Errorf("hwmonInputs(temp) = %+v, want %+v", got, want)
+	}
+	fans := hwmonInputs(chip, "k10temp", "fan", Fan, "RPM")
+	if len(fans) != 2 || fans[0].Value != 1200 || fans[0].Label != "fan1" {
+		t.Fatalf("hwmonInputs(fan) = %+v", fans)
+	}
+	// A stopped fan is a reading, not a missing one.
+	if data, err := json.Marshal(fans[1]); err != nil || !strings.Contains(string(data), `"value":0`) {
+		t.Errorf("json.Marshal(stopped fan) = %s, %v", data, err)
+	}
+	if got := thermalZoneCritical(filepath.Join(root, "zone0")); got != 105 {
+		t.Errorf("thermalZoneCritical() = %v, want 105", got)
+	}
+}
+
+func TestInitName(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		want  string
+	}{
+		{map[string]string{"proc/1/comm": "systemd\n"}, "systemd"},
+		{map[string]string{"proc/1/comm": "init\n", "run/systemd/system/": ""}, "systemd"},
+		{map[string]string{"proc/1/comm": "init\n", "run/openrc/": ""}, "OpenRC"},
+		{map[string]string{"proc/1/comm": "runit\n"}, "runit"},
+		{map[string]string{"proc/1/comm": "s6-svscan\n"}, "s6"},
+		{map[string]string{"proc/1/comm": "dinit\n"}, "dinit"},
+		{map[string]string{"proc/1/comm": "guile\n", "proc/1/exe": "-> /gnu/store/abc-shepherd/bin/shepherd"}, "GNU Shepherd"},
+		{map[string]string{"proc/1/comm": "init\n", "sbin/initctl": "\x7fELF upstart 1.13.2"}, "Upstart"},
+		{map[string]string{"proc/1/comm": "init\n"}, "SysVinit"},
+		{map[string]string{"proc/1/comm": "bash\n"}, ""},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeTree(t, root, tt.files)
+		if got := initName(root); got != tt.want {
+			t.Errorf("initName(%v) = %q, want %q", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestLockdownMode(t *testing.T) {
+	tests := map[string]string{
+		"none [integrity] confidentiality\n": "integrity",
+		"[none] integrity confidentiality\n": "none",
+		"none integrity [confidentiality]\n": "confidentiality",
+		"":                                   "",
+	}
+	for lockdown, want := range tests {
+		if got := lockdownMode(lockdown); got != want {
+			t.Errorf("lockdownMode(%q) = %q, want %q", lockdown, got, want)
+		}
+	}
+}
+
+func TestSecureBoot(t *testing.T) {
+	// efivarfs files start with the 4 byte attributes (here NV+BS+RT).
+	values := map[string]int{
+		"\x06\x00\x00\x00\x01": 1,
+		"\x06\x00\x00\x00\x00": 0,
+		"\x01\x00\x00\x00":     -1,
+		"":                     -1,
+	}
+	for data, want := range values {
+		if got := decodeEFIVarByte(data); got != want {
+			t.Errorf("decodeEFIVarByte(%q) = %d, want %d", data, got, want)
+		}
+	}
+
+	tests := []struct {
+		secureBoot int
+		setupMode  int
+		want       string
+	}{
+		{1, 0, "enabled"},
+		{0, 0, "disabled"},
+		{0, 1, "setup mode"},
+		{1, -1, "enabled"},
+		{-1, -1, ""},
+	}
+	for _, tt := range tests {
+		if got := secureBootState(tt.secureBoot, tt.setupMode); got != tt.want {
+			t.Errorf("secureBootState(%d, %d) = %q, want %q", tt.secureBoot, tt.setupMode, got, tt.want)
+		}
+	}
+}
+
+func TestParseEDID(t *testing.T) {
+	data := make([]byte, 128)
+	copy(data, []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00})
+	data[8], data[9] = 0x10, 0xac // DEL
+	data[10], data[11] = 0x34, 0x12
+	data[21], data[22] = 60, 34
+	// 1920x1080@60 (148.5MHz, 2200x1125 total), 597x336mm
+	copy(data[54:], []byte{0x02, 0x3a, 0x80, 0x18, 0x71, 0x38, 0x2d, 0x40,
+		0x58, 0x2c, 0x45, 0x00, 0x55, 0x50, 0x21, 0x00, 0x00, 0x1e})
+	copy(data[72:], append([]byte{0x00, 0x00, 0x00, 0xfc, 0x00}, []byte("DELL U2720Q\n ")...))
+
+	e, err := parseEDID(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.manufacturer != "DEL" || e.productCode != 0x1234 || e.model != "DELL U2720Q" {
+		t.Errorf("unexpected vendor block: %+v", e)
+	}
+	if e.width != 1920 || e.height != 1080 || e.refreshRate != 60 {
+		t.Errorf("unexpected preferred mode: %+v", e)
+	}
+	if e.widthMM != 597 || e.heightMM != 336 {
+		t.Errorf("unexpected size: %+v", e)
+	}
+	if _, err := parseEDID(data[:64]); err == nil {
+		t.Error("expected error for short EDID")
+	}
+}
+
+func TestParseCmdline(t *testing.T) {
+	got := parseCmdline("BOOT_IMAGE=/vmlinuz-6.1 root=UUID=abc ro quiet acpi_osi=\"Windows 2020\" -- --init-arg\n")
+	want := map[string]string{
+		"BOOT_IMAGE": "/vmlinuz-6.1",
+		"root":       "UUID=abc",
+		"ro":         "",
+		"quiet":      "",
+		"acpi_osi":   "Windows 2020",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseCmdline() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("parseCmdline()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestLocaleCategory(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"LC_ALL": "de_DE.UTF-8", "LC_TIME": "en_GB.UTF-8", "LANG": "en_US.UTF-8"}, "de_DE.UTF-8"},
+		{map[string]string{"LC_TIME": "en_GB.UTF-8", "LANG": "en_US.UTF-8"}, "en_GB.UTF-8"},
+		{map[string]string{"LC_NUMERIC": "fr_FR.UTF-8", "LANG": "en_US.UTF-8"}, "en_US.UTF-8"},
+		{map[string]string{"LC_ALL": "", "LANG": "ja_JP.UTF-8"}, "ja_JP.UTF-8"},
+		{map[string]string{}, "C"},
+	}
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := localeCategory("LC_TIME", getenv); got != tt.want {
+			t.Errorf("localeCategory(LC_TIME) with %v = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestZoneFromLink(t *testing.T) {
+	tests := map[string]string{
+		"../usr/share/zoneinfo/US/Pacific":        "US/Pacific",
+		"/usr/share/zoneinfo/America/Los_Angeles": "America/Los_Angeles",
+		"/usr/share/zoneinfo/posix/Europe/Berlin": "Europe/Berlin",
+		"/var/db/timezone/zoneinfo/Asia/Tokyo":    "Asia/Tokyo",
+		"/etc/localtime.custom":                   "",
+	}
+	for link, want := range tests {
+		if got := zoneFromLink(link); got != want {
+			t.Errorf("zoneFromLink(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+func TestX11Keyboard(t *testing.T) {
+	conf := `# Written by systemd-localed(8)
+Section "InputClass"
+        Identifier "system-keyboard"
+        MatchIsKeyboard "on"
+        Option "XkbLayout" "us,de"
+        Option "XkbModel" "pc105"
+	Option "XkbVariant" ",nodeadkeys"
+        # Option "XkbOptions" "ctrl:nocaps"
+EndSection
+`
+	want := map[string]string{"XkbLayout": "us,de", "XkbModel": "pc105", "XkbVariant": ",nodeadkeys"}
+	if got := x11Keyboard(conf); !reflect.DeepEqual(got, want) {
+		t.Errorf("x11Keyboard() = %v, want %v", got, want)
+	}
+	if got := x11Keyboard(""); len(got) != 0 {
+		t.Errorf("x11Keyboard(\"\") = %v", got)
+	}
+}
+
+func TestDecodeTaint(t *testing.T) {
+	got := decodeTaint("4097")
+	if len(got) != 2 || got[0] != "proprietary module" || got[1] != "out-of-tree module" {
+		t.Errorf("decodeTaint(4097) = %v", got)
+	}
+	if got := decodeTaint("0"); len(got) != 0 {
+		t.Errorf("decodeTaint(0) = %v", got)
+	}
+}
+
+func TestKernelConfigIn(t *testing.T) {
+	gz := &bytes.Buffer{}
+	w := gzip.NewWriter(gz)
+	if _, err := w.Write([]byte("CONFIG_BPF=y\nCONFIG_LOCALVERSION=\"-running\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"proc/config.gz":              gz.String(),
+		"boot/config-6.1.0-17-amd64":  "CONFIG_BPF=y\nCONFIG_LOCALVERSION=\"-boot\"\n",
+		"boot/config-6.1.0-18-amd64":  "# CONFIG_BPF is not set\nCONFIG_LOCALVERSION=\"-next\"\n",
+		"boot/config-5.10.0-26-amd64": "CONFIG_LOCALVERSION=\"-old\"\n",
+	})
+
+	tests := []struct {
+		release string
+		want    string
+	}{
+		{"6.1.0-17-amd64", "-running"},
+		{"6.1.0-18-amd64", "-next"},
+		{"5.10.0-26-amd64", "-old"},
+		{"4.19.0-6-amd64", ""},
+	}
+	for _, tt := range tests {
+		config := parseKernelConfig(kernelConfigIn(root, tt.release, "6.1.0-17-amd64"))
+		if got := config["CONFIG_LOCALVERSION"]; got != tt.want {
+			t.Errorf("kernelConfigIn(%s) CONFIG_LOCALVERSION = %q, want %q", tt.release, got, tt.want)
+		}
+	}
+	if got := parseKernelConfig(kernelConfigIn(root, "6.1.0-18-amd64", "6.1.0-17-amd64"))["CONFIG_BPF"]; got != "" {
+		t.Errorf("CONFIG_BPF of 6.1.0-18-amd64 = %q, want \"\"", got)
+	}
+}
+
+func TestKernelFlavour(t *testing.T) {
+	tests := map[string]string{
+		"5.15.0-91-generic":                  "generic",
+		"5.15.0-1034-aws":                    "cloud",
+		"6.1.0-13-rt-amd64":                  "rt",
+		"5.15.133.1-microsoft-standard-WSL2": "WSL2",
+		"4.19.128-microsoft-standard":        "WSL2",
+		"4.4.0-19041-Microsoft":              "WSL1",
+		"4.4.0-microsoft":                    "",
+		"6.6.8-arch1-1":                      "arch",
+		"6.18.44":                            "",
+	}
+	for release, want := range tests {
+		if got := kernelFlavour(release); got != want {
+			t.Errorf("kernelFlavour(%q) = %q, want %q", release, got, want)
+		}
+	}
+}
+
+func TestWSL(t *testing.T) {
+	tests := []struct {
+		kernel  string
+		procVer string
+		version int
+		build   string
+	}{
+		{"4.4.0-19041-Microsoft", "Linux version 4.4.0-19041-Microsoft (Microsoft@Microsoft.com) (gcc version 5.4.0 (GCC) ) #3636-Microsoft", 1, "19041"},
+		{"4.4.0-17763-Microsoft", "", 1, "17763"},
+		{"4.19.128-microsoft-standard", "Linux version 4.19.128-microsoft-standard (oe-user@oe-host) (gcc version 8.2.0 (GCC)) #1 SMP", 2, ""},
+		{"5.15.133.1-microsoft-standard-WSL2", "Linux version 5.15.133.1-microsoft-standard-WSL2 (root@1c602f52c2e4) #1 SMP", 2, ""},
+	}
+	for _, tt := range tests {
+		if got := wslVersion(tt.kernel, tt.procVer); got != tt.version {
+			t.Errorf("wslVersion(%q) = %d, want %d", tt.kernel, got, tt.version)
+		}
+		if got := wsl1WindowsBuild(tt.kernel); got != tt.build {
+			t.Errorf("wsl1WindowsBuild(%q) = %q, want %q", tt.kernel, got, tt.build)
+		}
+	}
+
+	if got := parseWindowsVer("\r\nMicrosoft Windows [Version 10.0.22631.4317]\r\n"); got != "10.0.22631.4317" {
+		t.Errorf("parseWindowsVer() = %q", got)
+	}
+}
+
+func TestChromeOSFromRelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     ChromeOS
+	}{
+		{
+			name: "host",
+			contents: `CHROMEOS_AUSERVER=https://tools.google.com/service/update2
+CHROMEOS_RELEASE_BOARD=octopus-signed-mp-v23keys
+CHROMEOS_RELEASE_CHROME_MILESTONE=120
+CHROMEOS_RELEASE_TRACK=stable-channel
+CHROMEOS_RELEASE_VERSION=15662.76.0
+DEVICETYPE=CHROMEBOOK
+`,
+			want: ChromeOS{Milestone: "120", Version: "15662.76.0", Channel: "stable", Board: "octopus-signed-mp-v23keys"},
+		},
+		{
+			name:     "crostini",
+			contents: "DISTRIB_ID=Debian\n",
+			want:     ChromeOS{},
+		},
+	}
+	for _, tt := range tests {
+		if got := chromeOSFromRelease(tt.contents); got != tt.want {
+			t.Errorf("%s: chromeOSFromRelease() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsARCVM(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		prop    string
+		want    bool
+	}{
+		{"console=hvc0 androidboot.arcvm=1 androidboot.hardware=bertha", "", true},
+		{"", "1", true},
+		{"androidboot.container=1 androidboot.hardware=cheets", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := isARCVM(parseCmdline(tt.cmdline), tt.prop); got != tt.want {
+			t.Errorf("isARCVM(%q, %q) = %v, want %v", tt.cmdline, tt.prop, got, tt.want)
+		}
+	}
+}
+
+func TestAndroidFromRoot(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"system/build.prop": "# begin build properties\n" +
+			"import /vendor/default.prop\n" +
+			"ro.build.version.release=13\n" +
+			"ro.build.version.sdk=33\n" +
+			"ro.build.version.security_patch=2023-10-05\n" +
+			"ro.product.system.brand=google\n" +
+			"ro.system.build.fingerprint=google/panther/panther:13/TQ3A.230901.001/10750268:user/release-keys\n",
+		"vendor/build.prop": "ro.product.vendor.model=Pixel 7\n" +
+			"ro.product.vendor.device=panther\n" +
+			"ro.vendor.product.cpu.abilist=arm64-v8a,armeabi-v7a,armeabi\n" +
+			"ro.build.version.sdk=30\n",
+	})
+
+	got := AndroidFromRoot(root)
+	if got.Release != "13" || got.SDK != "33" || got.SecurityPatch != "2023-10-05" {
+		t.Errorf("unexpected version: %+v", got)
+	}
+	if got.Brand != "google" || got.Model != "Pixel 7" || got.Device != "panther" {
+		t.Errorf("unexpected product: %+v", got)
+	}
+	if len(got.ABIs) != 3 || got.ABIs[0] != "arm64-v8a" {
+		t.Errorf("unexpected ABI list: %v", got.ABIs)
+	}
+	if got.Fingerprint == "" {
+		t.Error("missing fingerprint")
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// writeTree creates files below root. Paths ending in "/" are directories,
+// and contents starting with "-> " make a symbolic link.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, contents := range files {
+		full := filepath.Join(root, path)
+		if strings.HasSuffix(path, "/") {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(contents, "-> ") {
+			if err := os.Symlink(strings.TrimPrefix(contents, "-> "), full); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.WriteFile(full, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImageFromRoot(t *testing.T) {
+	ostree := map[string]string{
+		"run/ostree-booted":                        "",
+		"proc/cmdline":                             "BOOT_IMAGE=/vmlinuz ostree=/ostree/boot.1/fedora/abc/0 rw\n",
+		"ostree/boot.1":                            "-> boot.1.1",
+		"ostree/boot.1.1/fedora/abc/0":             "-> ../../../deploy/fedora/deploy/abc.0",
+		"ostree/deploy/fedora/deploy/abc.0/":       "",
+		"boot/loader/entries/ostree-1-fedora.conf": "version 1\noptions ostree=/ostree/boot.1/fedora/abc/0 rw\n",
+	}
+	staged := map[string]string{"run/ostree/staged-deployment": "{}"}
+	for k, v := range ostree {
+		staged[k] = v
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  *Image
+	}{
+		{
+			name: "nixos",
+			files: map[string]string{
+				"etc/os-release":                      "ID=nixos\n",
+				"run/current-system":                  "-> /nix/store/bbb-nixos-system",
+				"run/booted-system":                   "-> /nix/store/aaa-nixos-system",
+				"nix/var/nix/profiles/system":         "-> system-42-link",
+				"nix/var/nix/profiles/system-42-link": "-> /nix/store/bbb-nixos-system",
+				"nix/store/aaa-nixos-system/":         "",
+				"nix/store/bbb-nixos-system/":         "",
+			},
+			want: &Image{Type: "nixos", Immutable: true, Deployment: "42", PendingReboot: true},
+		},
+		{
+			name: "guix",
+			files: map[string]string{
+				"etc/os-release":           "ID=guix\n",
+				"run/current-system":       "-> /gnu/store/aaa-system",
+				"var/guix/profiles/system": "-> system-7-link",
+				"gnu/store/aaa-system/":    "",
+			},
+			want: nil,
+		},
+		{
+			name:  "ostree staged",
+			files: staged,
+			want:  &Image{Type: "rpm-ostree", Immutable: true, Deployment: "abc.0", PendingReboot: true},
+		},
+		{
+			name:  "ostree unstaged",
+			files: ostree,
+			want:  &Image{Type: "rpm-ostree", Immutable: true, Deployment: "abc.0"},
+		},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeTree(t, root, tt.files)
+		if got := ImageFromRoot(root); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ImageFromRoot() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetSupportStatus(t *testing.T) {
+	status := GetSupportStatus("rocky", "9.3")
+	if status == nil || !status.LTS || status.EOL.Year() != 2032 {
+		t.Errorf("GetSupportStatus(rocky, 9.3) = %+v", status)
+	}
+	status = GetSupportStatus("ubuntu", "18.04")
+	if status == nil || status.Supported {
+		t.Errorf("GetSupportStatus(ubuntu, 18.04) = %+v", status)
+	}
+	if data, err := json.Marshal(status); err != nil || !strings.Contains(string(data), `"supported":false`) {
+		t.Errorf("json.Marshal(unsupported) = %s, %v", data, err)
+	}
+	status = GetSupportStatus("opensuse-tumbleweed", "20240101")
+	if status == nil || !status.Supported || !status.EOL.IsZero() {
+		t.Errorf("GetSupportStatus(opensuse-tumbleweed) = %+v", status)
+	}
+	if status := GetSupportStatus("unknown", "1"); status != nil {
+		t.Errorf("GetSupportStatus(unknown) = %+v", status)
+	}
+}
+
+func TestLogos(t *testing.T) {
+	ids := map[string]string{}
+	for _, logo := range Logos() {
+		if len(logo.Names) == 0 || len(logo.Colors) == 0 || len(logo.Large) == 0 || len(logo.Small) == 0 {
+			t.Errorf("logo %s is incomplete: %+v", logo.ID, logo)
+		}
+		for _, id := range logo.IDs {
+			if other, ok := ids[id]; ok {
+				t.Errorf("os-release ID %s is used by %s and %s", id, other, logo.ID)
+			}
+			ids[id] = logo.ID
+		}
+		for _, line := range append(logo.Large, logo.Small...) {
+			for _, m := range colorPlaceholder.FindAllStringSubmatch(line, -1) {
+				if n := int(m[1][0] - '0'); n < 1 || n > len(logo.Colors) {
+					t.Errorf("logo %s uses %s outside its palette", logo.ID, m[0])
+				}
+			}
+		}
+	}
+}
+
+func TestLookupLogo(t *testing.T) {
+	tests := map[string]string{
+		"ubuntu":                       "ubuntu",
+		"Ubuntu Budgie 21.10":          "ubuntu",
+		"Bedrock Linux 0.7.28":         "bedrock",
+		"Armbian 23.8.1 bookworm":      "armbian",
+		"GoboLinux 017":                "gobolinux",
+		"Guix System 1.4.0":            "guix",
+		"Proxmox VE 8.1.3":             "proxmox",
+		"opensuse-tumbleweed":          "opensuse",
+		"Red Hat Enterprise Linux 9.3": "rhel",
+		"macOS Monterey 12.6":          "macos",
+	}
+	for name, want := range tests {
+		if logo, ok := LookupLogo(name); !ok || logo.ID != want {
+			t.Errorf("LookupLogo(%q) = %q, %v, want %q", name, logo.ID, ok, want)
+		}
+	}
+	if logo, ok := LookupLogo("Archer OS"); ok {
+		t.Errorf("LookupLogo(Archer OS) = %q", logo.ID)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := map[string]int{
+		"debian":                 6,
+		"\x1b[1;34mOS\x1b[0m: x": 5,
+		"日本語":                    6,
+		"███":                    3,
+		"e\u0301":                1,
+	}
+	for s, want := range tests {
+		if got := DisplayWidth(s); got != want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestLogoRender(t *testing.T) {
+	logo := Logo{Colors: []int{1}, Large: []string{"${c1}日本", "${c1}a"}}
+	got := logo.Render([]string{"OS: x", "Kernel: y", "Uptime: z"}, false, false)
+	want := "日本   OS: x\na      Kernel: y\n       Uptime: z\n"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	colored := logo.Render(nil, false, true)
+	if !strings.HasPrefix(colored, "\x1b[31m日本\x1b[0m") {
+		t.Errorf("Render() = %q", colored)
+	}
+}
+
+func TestRender(t *testing.T) {
+	info := OsInfo{Distro: "Ubuntu 22.04", Kernel: Kernel{Name: "Linux", Ver: "5.15.0", Arch: "x86_64"}, Uptime: "3 hours"}
+	for _, name := range Templates() {
+		out, err := RenderWithColor(info, name, false)
+		if err != nil || !strings.Contains(out, "Ubuntu 22.04") {
+			t.Errorf("RenderWithColor(%s) = %q, %v", name, out, err)
+		}
+	}
+
+	tests := map[string]string{
+		`{{ bytes 1536 }}`:               "1.5 KiB",
+		`{{ bytes 512 }}`:                "512 B",
+		`{{ duration 90061 }}`:           "1 day, 1 hour, 1 minute",
+		`{{ duration "2h30m" }}`:         "2 hours, 30 minutes",
+		`[{{ pad 5 "日本" }}]`:             "[日本 ]",
+		`[{{ padleft 4 .Kernel.Arch }}]`: "[x86_64]",
+		`{{ color "red" .Kernel.Name }}`: "Linux",
+		`{{ hostname }}`:                 "localhost",
+	}
+	for tmpl, want := range tests {
+		if got, err := RenderWithColor(info, tmpl, false); err != nil || got != want {
+			t.Errorf("RenderWithColor(%s) = %q, %v, want %q", tmpl, got, err, want)
+		}
+	}
+	withHost := info
+	withHost.Hostname = "web01"
+	if got, err := RenderWithColor(withHost, `{{ hostname }}`, false); err != nil || got != "web01" {
+		t.Errorf("hostname = %q, %v, want web01", got, err)
+	}
+	if got, _ := RenderWithColor(info, `{{ color "red" "x" }}`, true); got != "\x1b[31mx\x1b[0m" {
+		t.Errorf("color = %q", got)
+	}
+	info.Power = Power{{Name: "BAT0", Type: "Battery", Status: "Discharging", Capacity: intPtr(0)}, {Name: "BAT1", Type: "Battery", Status: "Full"}}
+	out, err := RenderWithColor(info, "neofetch", false)
+	if err != nil || !strings.Contains(out, "Battery: 0% Discharging") || !strings.Contains(out, "Battery: Full") {
+		t.Errorf("RenderWithColor(neofetch) = %q, %v", out, err)
+	}
+
+	for _, tmpl := range []string{`{{ .NoSuchField }}`, `{{ color "nocolor" "x" }}`, `{{ if }}`} {
+		if _, err := RenderWithColor(info, tmpl, true); err == nil {
+			t.Errorf("RenderWithColor(%s) succeeded", tmpl)
+		}
+	}
+}
+
+// countCollectors wraps the collectors so that the test can tell which
+// sections a collection ran.
+func countCollectors(t *testing.T) func(name string) int {
+	var mu sync.Mutex
+	counts := map[string]int{}
+	saved := collectors
+	t.Cleanup(func() { collectors = saved })
+
+	collectors = make([]collector, len(saved))
+	for i, c := range saved {
+		c := c
+		collectors[i] = collector{c.name, c.dynamic, func(info *OsInfo, env collectEnv) {
+			mu.Lock()
+			counts[c.name]++
+			mu.Unlock()
+			c.collect(info, env)
+		}}
+	}
+	return func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[name]
+	}
+}
+
+func TestCollector(t *testing.T) {
+	count := countCollectors(t)
+	c := NewCollector(WithFields("distro", "uptime"))
+	first := c.Get()
+	if first.Distro == "" || first.Uptime == "" || first.Shell != "" {
+		t.Fatalf("Collector.Get() = %+v", first)
+	}
+
+	// Static sections are not collected again by Refresh, dynamic ones are.
+	if info := c.Refresh(); info.Distro != first.Distro || info.Kernel.Ver != first.Kernel.Ver {
+		t.Errorf("Collector.Refresh() = %+v", info)
+	}
+	if count("distro") != 1 || count("uptime") != 2 || count("kernel") != 2 || count("shell") != 0 {
+		t.Errorf("Refresh ran distro %d, uptime %d, kernel %d, shell %d times",
+			count("distro"), count("uptime"), count("kernel"), count("shell"))
+	}
+	c.Get()
+	if count("distro") != 1 || count("uptime") != 2 {
+		t.Errorf("cached Get ran distro %d, uptime %d times", count("distro"), count("uptime"))
+	}
+	c.Invalidate()
+	if info := c.Get(); info.Distro != first.Distro || count("distro") != 2 {
+		t.Errorf("Collector.Get() after Invalidate = %+v, distro collected %d times", info, count("distro"))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			switch i % 3 {
+			case 0:
+				c.Get()
+			case 1:
+				c.Refresh()
+			case 2:
+				c.Invalidate()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkCollectorRefresh(b *testing.B) {
+	c := NewCollector()
+	c.Get()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Refresh()
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.toml": `fields = ["distro", "uptime"]
+include_identifiers = true
+timeout = "2s"
+disabled_probes = ["lsb_release"]
+
+[labels]
+distro = "Distribution"
+
+[units]
+temperature = "fahrenheit"
+`,
+		"config.yaml": `fields: [distro, uptime]
+include_identifiers: true
+timeout: 2s
+disabled_probes: [lsb_release]
+labels:
+  distro: Distribution
+units:
+  temperature: fahrenheit
+`,
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		opts, err := LoadOptions(path)
+		if err != nil {
+			t.Fatalf("LoadOptions(%s): %v", name, err)
+		}
+		if len(opts.Fields) != 2 || !opts.IncludeIdentifiers || opts.Timeout != 2*time.Second ||
+			opts.DisabledProbes[0] != "lsb_release" || opts.Labels["distro"] != "Distribution" ||
+			opts.Units.Temperature != "fahrenheit" {
+			t.Errorf("LoadOptions(%s) = %+v", name, opts)
+		}
+	}
+
+	for name, contents := range map[string]string{
+		"unknown-key.toml":   "colour = true\n",
+		"unknown-field.toml": `fields = ["kenel"]` + "\n",
+		"unknown-key.yaml":   "colour: true\n",
+		"bad-unit.yml":       "units:\n  temperature: kelvin\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadOptions(path); err == nil {
+			t.Errorf("LoadOptions(%s) succeeded", name)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if got, want := ConfigPath(), filepath.Join(dir, "osinfo", "config.toml"); got != want {
+		t.Errorf("ConfigPath() = %s, want %s", got, want)
+	}
+	if opts, err := LoadDefaultOptions(); err != nil || opts.Fields != nil {
+		t.Errorf("LoadDefaultOptions() without config = %+v, %v", opts, err)
+	}
+}
+
+func TestGetWithOptions(t *testing.T) {
+	info := GetWithOptions(Options{Fields: []string{"distro"}, DisabledProbes: []string{"lsb_release"}})
+	if info.Distro == "" || info.Kernel.Name == "" || info.Uptime != "" || info.Shell != "" {
+		t.Errorf("GetWithOptions(distro) = %+v", info)
+	}
+
+	info = Get(WithFields("uptime"), WithFields("shell"))
+	if info.Distro != "" || info.Uptime == "" || info.Shell == "" {
+		t.Errorf("Get(WithFields(uptime, shell)) = %+v", info)
+	}
+	serial := OsInfo{}
+	collect(&serial, &collectEnv{}, Options{}, false, false)
+	if concurrent := Get(); serial.Distro != concurrent.Distro || serial.Kernel.Ver != concurrent.Kernel.Ver {
+		t.Errorf("concurrent collection differs: %+v, %+v", serial, concurrent)
+	}
+
+	setProbes(Options{DisabledProbes: []string{"sh"}}, false)
+	defer setProbes(Options{}, false)
+	if _, err := runCmd("/bin/sh", "-c", "true"); err != errProbeDisabled {
+		t.Errorf("runCmd(disabled) = %v", err)
+	}
+	if existCmd("sh") {
+		t.Error("existCmd(disabled) = true")
+	}
+
+	setProbes(Options{}, true)
+	if localAndroid() != localAndroid() {
+		t.Error("build.prop is parsed more than once per collection")
+	}
+}
+
+func TestVirtualization(t *testing.T) {
+	tests := []struct {
+		dmi  []string
+		want string
+	}{
+		{[]string{"QEMU", "Standard PC (Q35 + ICH9, 2009)", "SeaBIOS"}, "qemu"},
+		{[]string{"QEMU", "Standard PC (i440FX + PIIX, 1996)", "KVM"}, "kvm"},
+		{[]string{"Microsoft Corporation", "Virtual Machine"}, "microsoft"},
+		{[]string{"Microsoft Corporation", "Surface Laptop 4"}, ""},
+		{[]string{"Amazon EC2", "m5.large"}, "amazon"},
+		{[]string{"innotek GmbH", "VirtualBox"}, "oracle"},
+		{[]string{"LENOVO", "20XW0055GE"}, ""},
+	}
+	for _, tt := range tests {
+		if got := dmiVirtualization(tt.dmi...); got != tt.want {
+			t.Errorf("dmiVirtualization(%v) = %q, want %q", tt.dmi, got, tt.want)
+		}
+	}
+
+	environ := map[string]string{
+		"PATH=/bin\x00container=lxc\x00HOME=/": "lxc",
+		"container=oci\x00":                    "docker",
+		"PATH=/bin\x00":                        "",
+	}
+	for env, want := range environ {
+		if got := containerFromEnviron(env); got != want {
+			t.Errorf("containerFromEnviron(%q) = %q, want %q", env, got, want)
+		}
+	}
+	if !cpuHasHypervisorFlag("processor\t: 0\nflags\t\t: fpu vme hypervisor lahf_lm\n") {
+		t.Error("cpuHasHypervisorFlag() = false")
+	}
+
+	id := strings.Repeat("0123456789abcdef", 4)
+	cgroups := []struct {
+		cgroup    string
+		mountinfo string
+		want      string
+	}{
+		{"12:pids:/docker/" + id + "\n0::/\n", "", id},
+		{"0::/system.slice/docker-" + id + ".scope\n", "", id},
+		{"0::/\n", "1 2 0:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n", id},
+		{"0::/\n", "1 2 0:1 / / rw - overlay overlay rw,lowerdir=/" + id + "\n", ""},
+	}
+	for _, tt := range cgroups {
+		if got := containerID(tt.cgroup, tt.mountinfo); got != tt.want {
+			t.Errorf("containerID(%q, %q) = %q, want %q", tt.cgroup, tt.mountinfo, got, tt.want)
+		}
+	}
+}
+
+func TestCloud(t *testing.T) {
+	tests := []struct {
+		dmi  []string
+		want *Cloud
+	}{
+		{[]string{"Amazon EC2", "m5.large", "Amazon EC2", ""}, &Cloud{Provider: "aws", Platform: "aws_ec2", MachineType: "m5.large"}},
+		{[]string{"Google", "Google Compute Engine", "Google", ""}, &Cloud{Provider: "gcp", Platform: "gcp_compute_engine"}},
+		{[]string{"Microsoft Corporation", "Virtual Machine", "Microsoft Corporation", azureAssetTag}, &Cloud{Provider: "azure", Platform: "azure_vm"}},
+		{[]string{"Microsoft Corporation", "Virtual Machine", "Microsoft Corporation", ""}, nil},
+		{[]string{"QEMU", "Standard PC (Q35 + ICH9, 2009)", "SeaBIOS", ""}, nil},
+	}
+	for _, tt := range tests {
+		if got := cloudFromDMI(tt.dmi[0], tt.dmi[1], tt.dmi[2], tt.dmi[3]); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("cloudFromDMI(%q) = %+v, want %+v", tt.dmi, got, tt.want)
+		}
+	}
+
+	envs := []struct {
+		env  map[string]string
+		want *Cloud
+	}{
+		{map[string]string{"AWS_LAMBDA_FUNCTION_NAME": "f", "AWS_REGION": "eu-west-1"}, &Cloud{Provider: "aws", Platform: "aws_lambda", Region: "eu-west-1"}},
+		{map[string]string{"K_SERVICE": "svc"}, &Cloud{Provider: "gcp", Platform: "gcp_cloud_run"}},
+		{map[string]string{"WEBSITE_SITE_NAME": "app", "FUNCTIONS_WORKER_RUNTIME": "node"}, &Cloud{Provider: "azure", Platform: "azure_functions"}},
+		{map[string]string{"HOME": "/root"}, nil},
+	}
+	for _, tt := range envs {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := cloudFromEnv(getenv); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("cloudFromEnv(%v) = %+v, want %+v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestMachineID(t *testing.T) {
+	ioreg := "+-o J314sAP  <class IOPlatformExpertDevice>\n    {\n      \"IOPlatformSerialNumber\" = \"C02XXXXX\"\n      \"IOPlatformUUID\" = \"4A3F2E1D-0000-1111-2222-333344445555\"\n    }\n"
+	if got := platformUUID(ioreg); got != "4A3F2E1D-0000-1111-2222-333344445555" {
+		t.Errorf("platformUUID() = %q", got)
+	}
+	reg := "\r\nHKEY_LOCAL_MACHINE\\SOFTWARE\\Microsoft\\Cryptography\r\n    MachineGuid    REG_SZ    6f1d0c2e-aaaa-bbbb-cccc-0123456789ab\r\n\r\n"
+	if got := machineGUID(reg); got != "6f1d0c2e-aaaa-bbbb-cccc-0123456789ab" {
+		t.Errorf("machineGUID() = %q", got)
+	}
+
+	fields := []string{"container", "cloud", "hostname", "machine_id"}
+	if err := (Options{Fields: fields}).Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	if info := Get(WithFields(fields...)); info.Hostname != "" || info.MachineID != "" {
+		t.Errorf("Get() = %q, %q without IncludeIdentifiers", info.Hostname, info.MachineID)
+	}
+	info := GetWithOptions(Options{Fields: fields, IncludeIdentifiers: true})
+	if info.Hostname == "" || info.Hostname != GetHostname() {
+		t.Errorf("Hostname = %q, want %q", info.Hostname, GetHostname())
+	}
+	if info.MachineID != GetMachineID() {
+		t.Errorf("MachineID = %q, want %q", info.MachineID, GetMachineID())
+	}
+	if !reflect.DeepEqual(info.Container, GetContainer()) || !reflect.DeepEqual(info.Cloud, GetCloud()) {
+		t.Errorf("Container, Cloud = %+v, %+v, want %+v, %+v", info.Container, info.Cloud, GetContainer(), GetCloud())
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snapshot.json")
+	want := TakeSnapshot(WithFields("distro", "uptime"))
+	if err := want.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CollectedAt.Equal(want.CollectedAt) || got.Distro != want.Distro || got.Kernel.Ver != want.Kernel.Ver {
+		t.Errorf("LoadSnapshot() = %+v, want %+v", got, want)
+	}
+	if changes, err := Diff(want.OsInfo, got.OsInfo); err != nil || len(changes) != 0 {
+		t.Errorf("Diff() of a saved and loaded snapshot = %v, %v", changes, err)
+	}
+
+	// The output of "osinfo --json" is a snapshot without collected_at.
+	encoded, err := want.OsInfo.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(dir, "plain.json")
+	if err := os.WriteFile(plain, encoded, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadSnapshot(plain); err != nil || !got.CollectedAt.IsZero() || got.Distro != want.Distro {
+		t.Errorf("LoadSnapshot(plain) = %+v, %v", got, err)
+	}
+
+	for name, contents := range map[string]string{
+		"empty.json":  "{}",
+		"newer.json":  `{"schema_version": 99}`,
+		"broken.json": `{"schema_version": 1`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSnapshot(path); err == nil {
+			t.Errorf("LoadSnapshot(%s) succeeded", name)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := OsInfo{
+		SchemaVersion: SchemaVersion,
+		Distro:        "Ubuntu 22.04.3 LTS",
+		Kernel: Kernel{
+			Ver:     "5.15.0-91-generic",
+			Tainted: []string{"P"},
+			Modules: []KernelModule{{Name: "nvidia", Size: 100}, {Name: "e1000e", Size: 10}, {Name: "snd", Size: 5, RefCount: 1, UsedBy: []string{"snd_hda_intel"}}},
+		},
+		Uptime:        "1 hour",
+		UptimeSeconds: 3600,
+		Shell:         "bash 5.1.16",
+		Power:         Power{{Name: "BAT0", Capacity: intPtr(80)}},
+		Image:         &Image{Type: "ostree", Immutable: true, Deployment: "fedora/39/x86_64/silverblue"},
+	}
+	after := before
+	after.Distro = "Ubuntu 22.04.4 LTS"
+	after.Kernel = Kernel{
+		Ver:     "5.15.0-92-generic",
+		Tainted: []string{"P", "O"},
+		Modules: []KernelModule{{Name: "nvidia", Size: 120}, {Name: "zfs", Size: 50}, {Name: "snd", Size: 5, RefCount: 3, UsedBy: []string{"snd_hda_intel", "snd_pcm"}}},
+	}
+	after.Uptime = "2 minutes"
+	after.UptimeSeconds = 120
+	after.Power = Power{{Name: "BAT0", Capacity: intPtr(75)}}
+	after.Cloud = &Cloud{Provider: "aws"}
+	after.Image = &Image{Type: "ostree", Immutable: true, Deployment: "fedora/40/x86_64/silverblue", PendingReboot: true}
+	after.Support = &SupportStatus{LTS: true, Supported: true}
+
+	want := []struct {
+		path     string
+		kind     ChangeKind
+		volatile bool
+	}{
+		{"cloud", ChangeAdded, false},
+		{"distro", ChangeModified, false},
+		{"image.deployment", ChangeModified, false},
+		{"image.pending_reboot", ChangeAdded, true},
+		{"kernel.modules[e1000e]", ChangeRemoved, false},
+		{"kernel.modules[nvidia].size", ChangeModified, false},
+		{"kernel.modules[snd].ref_count", ChangeModified, true},
+		{"kernel.modules[snd].used_by", ChangeModified, true},
+		{"kernel.modules[zfs]", ChangeAdded, false},
+		{"kernel.tainted", ChangeModified, false},
+		{"kernel.version", ChangeModified, false},
+		{"power[BAT0].capacity", ChangeModified, true},
+		{"support", ChangeAdded, true},
+		{"uptime", ChangeModified, true},
+		{"uptime_seconds", ChangeModified, true},
+	}
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Diff() = %v, want %d changes", changes, len(want))
+	}
+	for i, w := range want {
+		if c := changes[i]; c.Path != w.path || c.Kind != w.kind || c.Volatile != w.volatile {
+			t.Errorf("Diff()[%d] = %+v, want %+v", i, c, w)
+		}
+	}
+	if got := len(changes.Identity()) + len(changes.Volatile()); got != len(changes) {
+		t.Errorf("Identity() and Volatile() return %d changes, want %d", got, len(changes))
+	}
+
+	if got := changes[10].String(); got != `~ kernel.version: "5.15.0-91-generic" -> "5.15.0-92-generic"` {
+		t.Errorf("String() = %s", got)
+	}
+	if got := changes[5].String(); got != "~ kernel.modules[nvidia].size: 100 -> 120" {
+		t.Errorf("String() = %s", got)
+	}
+	if changes, err := Diff(before, before); err != nil || len(changes) != 0 {
+		t.Errorf("Diff() of equal OsInfo = %v, %v", changes, err)
+	}
+}
+
+// TestSchema checks that every key of the JSON encoding is described by
+// schema/osinfo.schema.json.
+func TestSchema(t *testing.T) {
+	data, err := os.ReadFile("schema/osinfo.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	info := Get()
+	info.Mac = &MacInfo{Name: "macOS", Ver: "12.1", BuildVer: "21C52"}
+	info.DMI = &DMI{SysVendor: "vendor"}
+	info.Board = &Board{Model: "board", Pi: &PiRevision{Code: "a02082"}}
+	info.Power = Power{{Name: "BAT0"}}
+	info.Sensors = []SensorReading{{Chip: "k10temp"}}
+	info.Displays = []Display{{Connector: "eDP-1"}}
+	info.Kernel.Modules = []KernelModule{{Name: "nvidia"}}
+	info.WSL = &WSL{Version: 2}
+	info.ChromeOS = &ChromeOS{Milestone: "120"}
+	info.Android = &Android{Release: "13"}
+	info.Image = &Image{Type: "nixos"}
+	info.Support = &SupportStatus{Supported: true}
+	info.Container = &Container{Runtime: "docker", ID: "0123"}
+	info.Cloud = &Cloud{Provider: "aws", Platform: "aws_ec2", Region: "eu-west-1", MachineType: "m5.large"}
+	info.Hostname = "host"
+	info.MachineID = "0123"
+
+	encoded, err := info.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := map[string]interface{}{}
+	if err := json.Unmarshal(encoded, &value); err != nil {
+		t.Fatal(err)
+	}
+	checkSchema(t, schema, schema, "", value)
+}
+
+func checkSchema(t *testing.T, root map[string]interface{}, schema map[string]interface{}, path string, value interface{}) {
+	t.Helper()
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		schema = root["$defs"].(map[string]interface{})[name].(map[string]interface{})
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, ok := schema["properties"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, child := range v {
+			prop, ok := props[key].(map[string]interface{})
+			if !ok {
+				t.Errorf("%s.%s is not described by the schema", path, key)
+				continue
+			}
+			checkSchema(t, root, prop, path+"."+key, child)
+		}
+	case []interface{}:
+		items, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s is not an array in the schema", path)
+			return
+		}
+		for _, child := range v {
+			checkSchema(t, root, items, path+"[]", child)
+		}
+	}
+}
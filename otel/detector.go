@@ -0,0 +1,196 @@
+//
+// osinfo/otel/detector.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otel maps osinfo host facts to OpenTelemetry resource attributes
+// of the semantic conventions (os.*, host.*, container.* and cloud.*):
+//
+//	res, err := resource.New(ctx, resource.WithDetectors(otel.NewDetector()))
+//
+// It lives in its own module so that osinfo does not depend on the
+// OpenTelemetry SDK.
+package otel
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/nao1215/osinfo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+// osBuildIDKey is os.build_id, which is newer than the semantic conventions
+// of this SDK.
+const osBuildIDKey = attribute.Key("os.build_id")
+
+// detectorFields are the OsInfo sections that Attributes reads.
+var detectorFields = []string{
+	"distro", "model", "mac", "android", "container", "cloud", "hostname", "machine_id",
+}
+
+// Detector is a resource.Detector for the operating system, host, container
+// and cloud attributes.
+type Detector struct {
+	opts osinfo.Options
+}
+
+var _ resource.Detector = (*Detector)(nil)
+
+// NewDetector returns a Detector. By default it collects only what
+// Attributes needs, with IncludeIdentifiers set because host.name and
+// host.id are identifiers; opts are applied on top of that.
+func NewDetector(opts ...osinfo.Option) *Detector {
+	d := &Detector{
+		opts: osinfo.Options{
+			Fields:             append([]string{}, detectorFields...),
+			IncludeIdentifiers: true,
+		},
+	}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	return d
+}
+
+// Detect collects the host information and returns it as a resource. The
+// deadline of ctx, if any, bounds every command osinfo runs unless the
+// options set a shorter Timeout.
+func (d *Detector) Detect(ctx context.Context) (*resource.Resource, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	opts := d.opts
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); opts.Timeout == 0 || left < opts.Timeout {
+			opts.Timeout = left
+		}
+	}
+	info := osinfo.GetWithOptions(opts)
+	return resource.NewWithAttributes(semconv.SchemaURL, Attributes(info)...), nil
+}
+
+// Attributes returns the resource attributes of info. Attributes whose
+// value is unknown are left out.
+func Attributes(info osinfo.OsInfo) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{}
+	add := func(key attribute.Key, value string) {
+		if value != "" {
+			attrs = append(attrs, key.String(value))
+		}
+	}
+
+	add(semconv.OSTypeKey, osType(info))
+	add(semconv.OSNameKey, osName(info))
+	add(semconv.OSVersionKey, osVersion(info))
+	if info.Mac != nil {
+		add(osBuildIDKey, info.Mac.BuildVer)
+	}
+	add(semconv.OSDescriptionKey, info.Distro)
+
+	add(semconv.HostNameKey, info.Hostname)
+	add(semconv.HostArchKey, hostArch(info.Kernel.Arch))
+	if info.Cloud != nil && info.Cloud.MachineType != "" {
+		add(semconv.HostTypeKey, info.Cloud.MachineType)
+	} else {
+		add(semconv.HostTypeKey, info.Model)
+	}
+	add(semconv.HostIDKey, info.MachineID)
+
+	// osinfo reports WSL as a container, like systemd-detect-virt does,
+	// but it is not one in the OpenTelemetry sense.
+	if info.Container != nil && info.Container.Runtime != "wsl" {
+		add(semconv.ContainerRuntimeKey, info.Container.Runtime)
+		add(semconv.ContainerIDKey, info.Container.ID)
+	}
+	if info.Cloud != nil {
+		add(semconv.CloudProviderKey, info.Cloud.Provider)
+		add(semconv.CloudPlatformKey, info.Cloud.Platform)
+		add(semconv.CloudRegionKey, info.Cloud.Region)
+	}
+	return attrs
+}
+
+// osType returns the os.type value for the kernel name reported by uname.
+func osType(info osinfo.OsInfo) string {
+	if info.Os == "Windows" {
+		return "windows"
+	}
+	switch name := strings.ToLower(info.Kernel.Name); name {
+	case "dragonfly":
+		return "dragonflybsd"
+	case "sunos":
+		return "solaris"
+	case "hp-ux":
+		return "hpux"
+	case "os/390", "z/os":
+		return "z_os"
+	default:
+		return name
+	}
+}
+
+func osName(info osinfo.OsInfo) string {
+	switch {
+	case info.DistroName != "":
+		return info.DistroName
+	case info.Mac != nil && info.Mac.Name != "":
+		return info.Mac.Name
+	case info.Android != nil:
+		return "Android"
+	}
+	return info.Os
+}
+
+func osVersion(info osinfo.OsInfo) string {
+	switch {
+	case info.VersionID != "":
+		return info.VersionID
+	case info.Mac != nil && info.Mac.Ver != "":
+		return info.Mac.Ver
+	case info.Android != nil:
+		return info.Android.Release
+	}
+	return ""
+}
+
+// hostArch returns the host.arch value for the machine hardware name
+// reported by uname. Unknown names are returned unchanged.
+func hostArch(machine string) string {
+	switch strings.ToLower(machine) {
+	case "x86_64", "amd64", "x64":
+		return "amd64"
+	case "aarch64", "arm64", "armv8l":
+		return "arm64"
+	case "i386", "i486", "i586", "i686", "x86", "i86pc":
+		return "x86"
+	case "ppc64", "ppc64le":
+		return "ppc64"
+	case "ppc", "powerpc":
+		return "ppc32"
+	case "ia64":
+		return "ia64"
+	case "s390x":
+		return "s390x"
+	}
+	if strings.HasPrefix(machine, "arm") {
+		return "arm32"
+	}
+	return machine
+}
@@ -0,0 +1,148 @@
+//
+// osinfo/otel/detector_test.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package otel
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/nao1215/osinfo"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+func TestAttributes(t *testing.T) {
+	tests := []struct {
+		name string
+		info osinfo.OsInfo
+		want map[string]string
+	}{
+		{
+			name: "ec2 docker",
+			info: osinfo.OsInfo{
+				Os:         "Linux",
+				Distro:     "Ubuntu 22.04.3 LTS",
+				DistroName: "Ubuntu",
+				VersionID:  "22.04",
+				Model:      "Amazon EC2 m5.large",
+				Kernel:     osinfo.Kernel{Name: "Linux", Arch: "x86_64"},
+				Hostname:   "ip-10-0-0-1",
+				MachineID:  "ec2d1e9fa4",
+				Container:  &osinfo.Container{Runtime: "docker", ID: "3c2b4e7"},
+				Cloud:      &osinfo.Cloud{Provider: "aws", Platform: "aws_ec2", MachineType: "m5.large"},
+			},
+			want: map[string]string{
+				"os.type":           "linux",
+				"os.name":           "Ubuntu",
+				"os.version":        "22.04",
+				"os.description":    "Ubuntu 22.04.3 LTS",
+				"host.name":         "ip-10-0-0-1",
+				"host.arch":         "amd64",
+				"host.type":         "m5.large",
+				"host.id":           "ec2d1e9fa4",
+				"container.runtime": "docker",
+				"container.id":      "3c2b4e7",
+				"cloud.provider":    "aws",
+				"cloud.platform":    "aws_ec2",
+			},
+		},
+		{
+			name: "macos",
+			info: osinfo.OsInfo{
+				Os:     "macOS",
+				Distro: "macOS 14.2.1 23C71",
+				Model:  "MacBookPro18,3",
+				Kernel: osinfo.Kernel{Name: "Darwin", Arch: "arm64"},
+				Mac:    &osinfo.MacInfo{Name: "macOS", Ver: "14.2.1", BuildVer: "23C71"},
+			},
+			want: map[string]string{
+				"os.type":        "darwin",
+				"os.name":        "macOS",
+				"os.version":     "14.2.1",
+				"os.build_id":    "23C71",
+				"os.description": "macOS 14.2.1 23C71",
+				"host.arch":      "arm64",
+				"host.type":      "MacBookPro18,3",
+			},
+		},
+		{
+			name: "wsl",
+			info: osinfo.OsInfo{
+				Os:        "Linux",
+				Kernel:    osinfo.Kernel{Name: "Linux", Arch: "armv7l"},
+				Container: &osinfo.Container{Runtime: "wsl"},
+			},
+			want: map[string]string{
+				"os.type":   "linux",
+				"os.name":   "Linux",
+				"host.arch": "arm32",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := map[string]string{}
+		for _, kv := range Attributes(tt.info) {
+			got[string(kv.Key)] = kv.Value.AsString()
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Attributes() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	res, err := NewDetector().Detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SchemaURL() != semconv.SchemaURL {
+		t.Errorf("SchemaURL() = %q", res.SchemaURL())
+	}
+	if got, ok := res.Set().Value(semconv.OSTypeKey); !ok || got.AsString() == "" {
+		t.Errorf("os.type = %v, %v", got, ok)
+	}
+	if got, _ := res.Set().Value(semconv.HostArchKey); runtime.GOARCH == "amd64" && got.AsString() != "amd64" {
+		t.Errorf("host.arch = %q", got.AsString())
+	}
+	if got, ok := res.Set().Value(semconv.HostNameKey); !ok || got.AsString() != osinfo.GetHostname() {
+		t.Errorf("host.name = %q, %v, want %q", got.AsString(), ok, osinfo.GetHostname())
+	}
+	// host.id is left out only on hosts without a machine ID.
+	if id := osinfo.GetMachineID(); id != "" {
+		if got, ok := res.Set().Value(semconv.HostIDKey); !ok || got.AsString() != id {
+			t.Errorf("host.id = %q, %v, want %q", got.AsString(), ok, id)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewDetector().Detect(ctx); err != context.Canceled {
+		t.Errorf("Detect() error = %v, want context.Canceled", err)
+	}
+
+	res, err = NewDetector(osinfo.WithOptions(osinfo.Options{Fields: detectorFields})).Detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []attribute.Key{semconv.HostNameKey, semconv.HostIDKey} {
+		if _, ok := res.Set().Value(key); ok {
+			t.Errorf("%s is set without IncludeIdentifiers", key)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+//
+// osinfo/power.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const powerSupplyDir = "/sys/class/power_supply"
+
+// PowerSupply is one entry of /sys/class/power_supply. Energy is in Wh.
+// Capacity is in percent, nil for supplies that do not report one. Scope
+// is "Device" for the batteries of peripherals (mice, keyboards, headsets),
+// which do not power the machine.
+type PowerSupply struct {
+	Name             string  `json:"name,omitempty" yaml:"name,omitempty"`
+	Type             string  `json:"type,omitempty" yaml:"type,omitempty"`
+	Status           string  `json:"status,omitempty" yaml:"status,omitempty"`
+	Scope            string  `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Capacity         *int    `json:"capacity,omitempty" yaml:"capacity,omitempty"`
+	EnergyNow        float64 `json:"energy_now_wh,omitempty" yaml:"energy_now_wh,omitempty"`
+	EnergyFull       float64 `json:"energy_full_wh,omitempty" yaml:"energy_full_wh,omitempty"`
+	EnergyFullDesign float64 `json:"energy_full_design_wh,omitempty" yaml:"energy_full_design_wh,omitempty"`
+	CycleCount       int     `json:"cycle_count,omitempty" yaml:"cycle_count,omitempty"`
+	Health           float64 `json:"health,omitempty" yaml:"health,omitempty"`
+	Online           bool    `json:"online,omitempty" yaml:"online,omitempty"`
+}
+
+// Power is the list of power supplies (AC adapters, batteries, UPS).
+type Power []PowerSupply
+
+// Batteries returns only the batteries of the machine, leaving out those
+// of peripherals.
+func (p Power) Batteries() []PowerSupply {
+	batteries := []PowerSupply{}
+	for _, v := range p {
+		if v.Type == "Battery" && !v.isDevice() {
+			batteries = append(batteries, v)
+		}
+	}
+	return batteries
+}
+
+// OnAC reports whether the machine runs on external power. A machine
+// without a battery is always on AC.
+func (p Power) OnAC() bool {
+	for _, v := range p {
+		if v.Type != "Battery" && !v.isDevice() && v.Online {
+			return true
+		}
+	}
+	for _, v := range p.Batteries() {
+		if v.Status == "Discharging" {
+			return false
+		}
+	}
+	return true
+}
+
+// GetPower returns the power supplies. It returns an empty list on machines
+// without /sys/class/power_supply entries.
+func GetPower() Power {
+	power := Power{}
+	dirs, err := filepath.Glob(filepath.Join(powerSupplyDir, "*"))
+	if err != nil {
+		return power
+	}
+
+	for _, dir := range dirs {
+		power = append(power, powerSupply(dir))
+	}
+	return power
+}
+
+// isDevice reports whether the supply powers a peripheral, not the machine.
+func (s PowerSupply) isDevice() bool {
+	return s.Scope == "Device"
+}
+
+func powerSupply(dir string) PowerSupply {
+	supply := PowerSupply{
+		Name:       filepath.Base(dir),
+		Type:       sysfsString(filepath.Join(dir, "type")),
+		Status:     sysfsString(filepath.Join(dir, "status")),
+		Scope:      sysfsString(filepath.Join(dir, "scope")),
+		Capacity:   sysfsOptionalInt(filepath.Join(dir, "capacity")),
+		CycleCount: sysfsInt(filepath.Join(dir, "cycle_count")),
+		Online:     sysfsInt(filepath.Join(dir, "online")) == 1,
+	}
+
+	if isFile(filepath.Join(dir, "energy_full")) {
+		// µWh
+		supply.EnergyNow = microToUnit(sysfsInt(filepath.Join(dir, "energy_now")))
+		supply.EnergyFull = microToUnit(sysfsInt(filepath.Join(dir, "energy_full")))
+		supply.EnergyFullDesign = microToUnit(sysfsInt(filepath.Join(dir, "energy_full_design")))
+	} else if isFile(filepath.Join(dir, "charge_full")) {
+		// µAh, converted with the design voltage in µV
+		volt := microToUnit(sysfsInt(filepath.Join(dir, "voltage_min_design")))
+		supply.EnergyNow = microToUnit(sysfsInt(filepath.Join(dir, "charge_now"))) * volt
+		supply.EnergyFull = microToUnit(sysfsInt(filepath.Join(dir, "charge_full"))) * volt
+		supply.EnergyFullDesign = microToUnit(sysfsInt(filepath.Join(dir, "charge_full_design"))) * volt
+	}
+
+	if supply.EnergyFullDesign > 0 {
+		supply.Health = supply.EnergyFull / supply.EnergyFullDesign
+	}
+	if supply.Type == "Battery" && supply.Status == "" {
+		supply.Status = "Unknown"
+	}
+	return supply
+}
+
+func sysfsString(path string) string {
+	return strings.TrimSpace(readFile(path))
+}
+
+func sysfsInt(path string) int {
+	num, err := strconv.Atoi(sysfsString(path))
+	if err != nil {
+		return 0
+	}
+	return num
+}
+
+// sysfsOptionalInt returns nil when the attribute is missing or not a number.
+func sysfsOptionalInt(path string) *int {
+	num, err := strconv.Atoi(sysfsString(path))
+	if err != nil {
+		return nil
+	}
+	return &num
+}
+
+func microToUnit(micro int) float64 {
+	return float64(micro) / 1000000
+}
@@ -0,0 +1,144 @@
+//
+// osinfo/prometheus/prometheus.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prometheus exports osinfo host facts in the Prometheus text
+// exposition format, over HTTP or as a node_exporter textfile:
+//
+//	osinfo_info{os="Linux",distro="Ubuntu 22.04.3 LTS",distro_id="ubuntu",...} 1
+//	osinfo_boot_time_seconds 1700000000
+//	osinfo_uptime_seconds 86400
+//
+// It has no dependency on the Prometheus client library.
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nao1215/osinfo"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// infoLabels are the labels of osinfo_info, in output order.
+var infoLabels = []struct {
+	name  string
+	value func(info osinfo.OsInfo) string
+}{
+	{"os", func(info osinfo.OsInfo) string { return info.Os }},
+	{"distro", func(info osinfo.OsInfo) string { return info.Distro }},
+	{"distro_id", func(info osinfo.OsInfo) string { return info.DistroID }},
+	{"version_id", func(info osinfo.OsInfo) string { return info.VersionID }},
+	{"kernel", func(info osinfo.OsInfo) string { return info.Kernel.Ver }},
+	{"arch", func(info osinfo.OsInfo) string { return info.Kernel.Arch }},
+	{"model", func(info osinfo.OsInfo) string { return info.Model }},
+	{"virtualization", func(info osinfo.OsInfo) string { return info.Virtualization }},
+}
+
+// Write writes the metrics of info. Boot time and uptime are left out when
+// they are unknown.
+func Write(w io.Writer, info osinfo.OsInfo) error {
+	labels := make([]string, 0, len(infoLabels))
+	for _, l := range infoLabels {
+		labels = append(labels, l.name+`="`+escapeLabel(l.value(info))+`"`)
+	}
+
+	var b bytes.Buffer
+	writeMetric(&b, "osinfo_info", "Operating system information, always 1.",
+		"{"+strings.Join(labels, ",")+"}", "1")
+	if info.BootTime > 0 {
+		writeMetric(&b, "osinfo_boot_time_seconds", "Unix time the system booted.",
+			"", strconv.FormatInt(info.BootTime, 10))
+	}
+	if info.UptimeSeconds > 0 {
+		writeMetric(&b, "osinfo_uptime_seconds", "Seconds since the system booted.",
+			"", strconv.FormatInt(info.UptimeSeconds, 10))
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func writeMetric(b *bytes.Buffer, name string, help string, labels string, value string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(b, "%s%s %s\n", name, labels, value)
+}
+
+// escapeLabel escapes a label value: backslash, double quote and line feed.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// Handler serves the metrics like promhttp.Handler does. Every scrape
+// refreshes the dynamic sections of c; the static ones are collected once.
+func Handler(c *osinfo.Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var b bytes.Buffer
+		if err := Write(&b, c.Refresh()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+		if r.Method == http.MethodGet {
+			w.Write(b.Bytes())
+		}
+	})
+}
+
+// WriteTextfile writes the metrics to path for the textfile collector of
+// node_exporter. The file is written to a temporary file in the same
+// directory and renamed, so node_exporter never reads a partial file.
+// The name of the file must end with ".prom".
+func WriteTextfile(path string, info osinfo.OsInfo) error {
+	if filepath.Ext(path) != ".prom" {
+		return fmt.Errorf("%s: textfile collector files must end with .prom", path)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(tmp, info); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,112 @@
+//
+// osinfo/prometheus/prometheus_test.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package prometheus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/osinfo"
+)
+
+func TestWrite(t *testing.T) {
+	info := osinfo.OsInfo{
+		Os:             "Linux",
+		Distro:         `Ubuntu "Jammy" 22.04`,
+		DistroID:       "ubuntu",
+		VersionID:      "22.04",
+		Kernel:         osinfo.Kernel{Ver: "5.15.0-91-generic", Arch: "x86_64"},
+		Model:          `C:\Machine`,
+		Virtualization: "kvm",
+		BootTime:       1700000000,
+		UptimeSeconds:  86400,
+	}
+	var b bytes.Buffer
+	if err := Write(&b, info); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP osinfo_info Operating system information, always 1.
+# TYPE osinfo_info gauge
+osinfo_info{os="Linux",distro="Ubuntu \"Jammy\" 22.04",distro_id="ubuntu",version_id="22.04",kernel="5.15.0-91-generic",arch="x86_64",model="C:\\Machine",virtualization="kvm"} 1
+# HELP osinfo_boot_time_seconds Unix time the system booted.
+# TYPE osinfo_boot_time_seconds gauge
+osinfo_boot_time_seconds 1700000000
+# HELP osinfo_uptime_seconds Seconds since the system booted.
+# TYPE osinfo_uptime_seconds gauge
+osinfo_uptime_seconds 86400
+`
+	if b.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler(osinfo.NewCollector(osinfo.WithFields("distro", "uptime"))))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != ContentType {
+		t.Errorf("GET = %d, %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), "\nosinfo_info{") {
+		t.Errorf("GET body = %s", body)
+	}
+
+	resp, err = http.Post(server.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d", resp.StatusCode)
+	}
+}
+
+func TestWriteTextfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "osinfo.prom")
+	if err := WriteTextfile(path, osinfo.OsInfo{Os: "Linux"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `osinfo_info{os="Linux"`) {
+		t.Errorf("textfile = %s, %v", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+
+	if err := WriteTextfile(filepath.Join(dir, "osinfo.txt"), osinfo.OsInfo{}); err == nil {
+		t.Error("WriteTextfile(osinfo.txt) succeeded")
+	}
+}
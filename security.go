@@ -0,0 +1,142 @@
+//
+// osinfo/security.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const efiGlobalVariable = "8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// Security is the security posture of the running kernel and firmware.
+// Empty strings mean the feature is not available on this machine.
+type Security struct {
+	SELinux       string   `json:"selinux,omitempty" yaml:"selinux,omitempty"`
+	SELinuxPolicy string   `json:"selinux_policy,omitempty" yaml:"selinux_policy,omitempty"`
+	AppArmor      bool     `json:"apparmor,omitempty" yaml:"apparmor,omitempty"`
+	LSM           []string `json:"lsm,omitempty" yaml:"lsm,omitempty"`
+	Lockdown      string   `json:"lockdown,omitempty" yaml:"lockdown,omitempty"`
+	SecureBoot    string   `json:"secure_boot,omitempty" yaml:"secure_boot,omitempty"`
+	TPM           bool     `json:"tpm,omitempty" yaml:"tpm,omitempty"`
+	TPMVersion    string   `json:"tpm_version,omitempty" yaml:"tpm_version,omitempty"`
+}
+
+// GetSecurity returns the SELinux, AppArmor, LSM, lockdown, Secure Boot
+// and TPM state.
+func GetSecurity() Security {
+	security := Security{
+		AppArmor:   sysfsString("/sys/module/apparmor/parameters/enabled") == "Y",
+		LSM:        lsmList(readFile("/sys/kernel/security/lsm")),
+		Lockdown:   lockdownMode(readFile("/sys/kernel/security/lockdown")),
+		SecureBoot: secureBoot(),
+	}
+	security.SELinux, security.SELinuxPolicy = selinux()
+	security.TPM, security.TPMVersion = tpm()
+	return security
+}
+
+// selinux returns the current mode and the configured policy type.
+func selinux() (string, string) {
+	config := parseKeyValue(readFile("/etc/selinux/config"))
+	policy := config["SELINUXTYPE"]
+
+	switch sysfsString("/sys/fs/selinux/enforce") {
+	case "1":
+		return "enforcing", policy
+	case "0":
+		return "permissive", policy
+	}
+	if !emptyStr(config["SELINUX"]) {
+		return "disabled", policy
+	}
+	return "", policy
+}
+
+func lsmList(lsm string) []string {
+	list := []string{}
+	for _, v := range strings.Split(strings.TrimSpace(lsm), ",") {
+		if !emptyStr(v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// lockdownMode returns the selected mode of "none [integrity] confidentiality".
+func lockdownMode(lockdown string) string {
+	m := regexp.MustCompile(`\[(\w+)\]`).FindStringSubmatch(lockdown)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// secureBoot decodes the SecureBoot and SetupMode EFI variables. The first
+// four bytes of an efivarfs file are the variable attributes.
+func secureBoot() string {
+	if !isDir("/sys/firmware/efi") {
+		return ""
+	}
+
+	return secureBootState(efiVarByte("SecureBoot-"+efiGlobalVariable), efiVarByte("SetupMode-"+efiGlobalVariable))
+}
+
+// secureBootState names the state of the SecureBoot and SetupMode values;
+// -1 is a missing variable.
+func secureBootState(secureBoot int, setupMode int) string {
+	if setupMode == 1 {
+		return "setup mode"
+	}
+	switch secureBoot {
+	case 1:
+		return "enabled"
+	case 0:
+		return "disabled"
+	}
+	return ""
+}
+
+func efiVarByte(name string) int {
+	return decodeEFIVarByte(readFile(filepath.Join("/sys/firmware/efi/efivars", name)))
+}
+
+// decodeEFIVarByte returns the first byte of the value of an efivarfs
+// file, after the four attribute bytes, or -1 if there is none.
+func decodeEFIVarByte(data string) int {
+	if len(data) < 5 {
+		return -1
+	}
+	return int(data[4])
+}
+
+func tpm() (bool, string) {
+	devices, _ := filepath.Glob("/sys/class/tpm/tpm[0-9]*")
+	if len(devices) == 0 {
+		return false, ""
+	}
+
+	major := sysfsString(filepath.Join(devices[0], "tpm_version_major"))
+	if emptyStr(major) {
+		return true, ""
+	}
+	if major == "1" {
+		return true, "1.2"
+	}
+	return true, major + ".0"
+}
@@ -0,0 +1,137 @@
+//
+// osinfo/sensors.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SensorKind is the physical quantity of a sensor reading.
+type SensorKind string
+
+const (
+	// Temperature : value in °C
+	Temperature SensorKind = "temperature"
+	// Fan : value in RPM
+	Fan SensorKind = "fan"
+	// Voltage : value in V
+	Voltage SensorKind = "voltage"
+)
+
+// SensorReading is a value read from hwmon or a thermal zone. Critical is
+// zero when the driver reports no critical threshold.
+type SensorReading struct {
+	Chip     string     `json:"chip,omitempty" yaml:"chip,omitempty"`
+	Label    string     `json:"label,omitempty" yaml:"label,omitempty"`
+	Kind     SensorKind `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Value    float64    `json:"value" yaml:"value"`
+	Unit     string     `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Critical float64    `json:"critical,omitempty" yaml:"critical,omitempty"`
+}
+
+// GetSensors returns the hwmon and thermal zone readings.
+func GetSensors() []SensorReading {
+	readings := hwmonReadings()
+	return append(readings, thermalZoneReadings()...)
+}
+
+func hwmonReadings() []SensorReading {
+	readings := []SensorReading{}
+	chips, _ := filepath.Glob("/sys/class/hwmon/hwmon*")
+	sort.Strings(chips)
+
+	for _, chip := range chips {
+		name := sysfsString(filepath.Join(chip, "name"))
+		readings = append(readings, hwmonInputs(chip, name, "temp", Temperature, "°C")...)
+		readings = append(readings, hwmonInputs(chip, name, "fan", Fan, "RPM")...)
+		readings = append(readings, hwmonInputs(chip, name, "in", Voltage, "V")...)
+	}
+	return readings
+}
+
+func hwmonInputs(chip string, name string, prefix string, kind SensorKind, unit string) []SensorReading {
+	readings := []SensorReading{}
+	inputs, _ := filepath.Glob(filepath.Join(chip, prefix+"[0-9]*_input"))
+	sort.Strings(inputs)
+
+	for _, input := range inputs {
+		if !IsReadable(input) {
+			continue
+		}
+		base := strings.TrimSuffix(input, "_input")
+		label := sysfsString(base + "_label")
+		if emptyStr(label) {
+			label = filepath.Base(base)
+		}
+
+		reading := SensorReading{
+			Chip:  name,
+			Label: label,
+			Kind:  kind,
+			Value: hwmonValue(kind, sysfsInt(input)),
+			Unit:  unit,
+		}
+		if isFile(base + "_crit") {
+			reading.Critical = hwmonValue(kind, sysfsInt(base+"_crit"))
+		}
+		readings = append(readings, reading)
+	}
+	return readings
+}
+
+// hwmonValue converts the sysfs fixed point value: temperatures are in
+// millidegree Celsius, voltages in millivolt, and fans already in RPM.
+func hwmonValue(kind SensorKind, raw int) float64 {
+	if kind == Fan {
+		return float64(raw)
+	}
+	return float64(raw) / 1000
+}
+
+func thermalZoneReadings() []SensorReading {
+	readings := []SensorReading{}
+	zones, _ := filepath.Glob("/sys/class/thermal/thermal_zone*")
+	sort.Strings(zones)
+
+	for _, zone := range zones {
+		if !isFile(filepath.Join(zone, "temp")) {
+			continue
+		}
+		readings = append(readings, SensorReading{
+			Chip:     filepath.Base(zone),
+			Label:    sysfsString(filepath.Join(zone, "type")),
+			Kind:     Temperature,
+			Value:    hwmonValue(Temperature, sysfsInt(filepath.Join(zone, "temp"))),
+			Unit:     "°C",
+			Critical: thermalZoneCritical(zone),
+		})
+	}
+	return readings
+}
+
+func thermalZoneCritical(zone string) float64 {
+	types, _ := filepath.Glob(filepath.Join(zone, "trip_point_*_type"))
+	for _, v := range types {
+		if sysfsString(v) == "critical" {
+			temp := strings.TrimSuffix(v, "_type") + "_temp"
+			return hwmonValue(Temperature, sysfsInt(temp))
+		}
+	}
+	return 0
+}
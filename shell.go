@@ -17,9 +17,7 @@
 package osinfo
 
 import (
-	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -63,7 +61,7 @@ func removeUnusedInfoFromVer(ver string) string {
 func bashVer() string {
 	ver := os.Getenv("BASH_VERSION")
 	if emptyStr(ver) {
-		version, err := exec.Command("bash", "-c", "printf %s \"$BASH_VERSION\"").Output()
+		version, err := runCmd("bash", "-c", "printf %s \"$BASH_VERSION\"")
 		if err != nil {
 			return ""
 		}
@@ -75,9 +73,8 @@ func bashVer() string {
 
 func kshVer() string {
 	shell := os.Getenv("SHELL")
-	version, err := exec.Command(shell, "-c", "printf %s \"$KSH_VERSION\"").Output()
+	version, err := runCmd(shell, "-c", "printf %s \"$KSH_VERSION\"")
 	if err != nil {
-		fmt.Println(err)
 		return ""
 	}
 	ver := string(version)
@@ -88,7 +85,7 @@ func kshVer() string {
 func oshVer() string {
 	ver := os.Getenv("OIL_VERSION")
 	if emptyStr(ver) {
-		version, err := exec.Command("bash", "-c", "printf %s \"$OIL_VERSION\"").Output()
+		version, err := runCmd("bash", "-c", "printf %s \"$OIL_VERSION\"")
 		if err != nil {
 			return ""
 		}
@@ -99,9 +96,8 @@ func oshVer() string {
 
 func tcshVer() string {
 	shell := os.Getenv("SHELL")
-	version, err := exec.Command(shell, "-c", "printf %s $tcsh").Output()
+	version, err := runCmd(shell, "-c", "printf %s $tcsh")
 	if err != nil {
-		fmt.Println(err)
 		return ""
 	}
 	return strings.TrimSpace(string(version))
@@ -109,9 +105,8 @@ func tcshVer() string {
 
 func yashVer() string {
 	shell := os.Getenv("SHELL")
-	version, err := exec.Command(shell, "--version").Output()
+	version, err := runCmd(shell, "--version")
 	if err != nil {
-		fmt.Println(err)
 		return ""
 	}
 	verList := strings.Split(string(version), "\n")
@@ -123,9 +118,8 @@ func yashVer() string {
 
 func nuShellVer() string {
 	shell := os.Getenv("SHELL")
-	verion, err := exec.Command(shell, "-c \"version | get version\"").Output()
+	verion, err := runCmd(shell, "-c \"version | get version\"")
 	if err != nil {
-		fmt.Println(err)
 		return ""
 	}
 	ver := removeStringByRegexp(string(verion), "nu")
@@ -134,9 +128,8 @@ func nuShellVer() string {
 
 func otherShell() string {
 	shell := os.Getenv("SHELL")
-	version, err := exec.Command(shell, "--version").Output()
+	version, err := runCmd(shell, "--version")
 	if err != nil {
-		fmt.Println(err)
 		return ""
 	}
 	verList := strings.Split(string(version), "\n")
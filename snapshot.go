@@ -0,0 +1,330 @@
+//
+// osinfo/snapshot.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// volatileFields are the sections, or single fields, that change without
+// anything being changed on the host: they are reported by Diff, but
+// marked Volatile. "support" follows the calendar (an EOL date passes),
+// "image.pending_reboot" an update staged in the background and the users
+// of a kernel module the devices that are open; the rest of "image" is the
+// deployment that is booted, which is a change of the host. List elements
+// are matched with "[*]".
+var volatileFields = map[string]bool{
+	"uptime":                      true,
+	"uptime_seconds":              true,
+	"boot_time":                   true,
+	"power":                       true,
+	"sensors":                     true,
+	"support":                     true,
+	"image.pending_reboot":        true,
+	"kernel.modules[*].ref_count": true,
+	"kernel.modules[*].used_by":   true,
+}
+
+// listElement matches the "[name]" of a list element in a Change path.
+var listElement = regexp.MustCompile(`\[[^\]]*\]`)
+
+// listKeys are the fields that identify the elements of lists of objects
+// (kernel modules and power supplies, displays, sensors), tried in order.
+var listKeys = [][]string{{"name"}, {"connector"}, {"chip", "label"}}
+
+// Snapshot is an OsInfo with the time it was collected. It is encoded as
+// the OsInfo plus "collected_at", so the output of "osinfo --json" loads
+// as a Snapshot too.
+type Snapshot struct {
+	CollectedAt time.Time `json:"collected_at" yaml:"collected_at"`
+	OsInfo      `yaml:",inline"`
+}
+
+// TakeSnapshot collects what the options select, like Get does.
+func TakeSnapshot(opts ...Option) Snapshot {
+	return Snapshot{CollectedAt: time.Now().UTC(), OsInfo: Get(opts...)}
+}
+
+// JSON returns the indented JSON encoding of the Snapshot.
+func (s Snapshot) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// YAML returns the YAML encoding of the Snapshot.
+func (s Snapshot) YAML() ([]byte, error) {
+	return yaml.Marshal(s)
+}
+
+// Save writes the Snapshot to path as JSON.
+func (s Snapshot) Save(path string) error {
+	data, err := s.JSON()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// LoadSnapshot reads a Snapshot written by Save or by "osinfo --json".
+// Snapshots of a newer SchemaVersion are rejected.
+func LoadSnapshot(path string) (Snapshot, error) {
+	s := Snapshot{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	switch {
+	case s.SchemaVersion == 0:
+		return s, fmt.Errorf("%s: not an osinfo snapshot (no schema_version)", path)
+	case s.SchemaVersion > SchemaVersion:
+		return s, fmt.Errorf("%s: schema version %d is newer than %d", path, s.SchemaVersion, SchemaVersion)
+	}
+	return s, nil
+}
+
+// ChangeKind is what happened to a field between two collections.
+type ChangeKind string
+
+const (
+	// ChangeAdded : the field is only in the new OsInfo
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved : the field is only in the old OsInfo
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified : the field has a different value
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change is a field that differs between two collections. Path is the
+// JSON path of the field; elements of lists of objects are addressed by
+// their name, e.g. "kernel.modules[nvidia].size". Old and New are the
+// JSON values (string, json.Number, bool, []interface{} or
+// map[string]interface{}), nil when the field is absent. Volatile changes
+// are those of uptime, boot time, power supplies, sensors, support status,
+// pending reboots and kernel module users, which change on their own.
+type Change struct {
+	Path     string      `json:"path" yaml:"path"`
+	Kind     ChangeKind  `json:"kind" yaml:"kind"`
+	Old      interface{} `json:"old,omitempty" yaml:"old,omitempty"`
+	New      interface{} `json:"new,omitempty" yaml:"new,omitempty"`
+	Volatile bool        `json:"volatile,omitempty" yaml:"volatile,omitempty"`
+}
+
+// String formats the change like a line of a diff:
+//
+//	~ kernel.version: "5.15.0-91-generic" -> "5.15.0-92-generic"
+//	+ kernel.modules[zfs]: {"name":"zfs","size":5890048,"state":"Live"}
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return "+ " + c.Path + ": " + diffValueString(c.New)
+	case ChangeRemoved:
+		return "- " + c.Path + ": " + diffValueString(c.Old)
+	default:
+		return "~ " + c.Path + ": " + diffValueString(c.Old) + " -> " + diffValueString(c.New)
+	}
+}
+
+func diffValueString(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// Changes is the result of Diff, ordered by path.
+type Changes []Change
+
+// Identity returns the changes to what the host is (kernel, distro,
+// drivers, shell, ...), leaving out the volatile ones.
+func (c Changes) Identity() Changes {
+	return c.filter(false)
+}
+
+// Volatile returns only the volatile changes.
+func (c Changes) Volatile() Changes {
+	return c.filter(true)
+}
+
+func (c Changes) filter(volatile bool) Changes {
+	out := Changes{}
+	for _, change := range c {
+		if change.Volatile == volatile {
+			out = append(out, change)
+		}
+	}
+	return out
+}
+
+// Diff returns the fields that differ from a to b. Fields are compared in
+// their JSON encoding, so a section that was not collected in one of them
+// shows up as added or removed. It returns an error if a or b can not be
+// encoded.
+func Diff(a, b OsInfo) (Changes, error) {
+	changes := Changes{}
+	before, err := jsonTree(a)
+	if err != nil {
+		return nil, err
+	}
+	after, err := jsonTree(b)
+	if err != nil {
+		return nil, err
+	}
+	delete(before, "schema_version")
+	delete(after, "schema_version")
+	diffObjects(&changes, "", before, after)
+	return changes, nil
+}
+
+// jsonTree decodes the JSON encoding of info, keeping numbers as
+// json.Number so that they print the way they are encoded.
+func jsonTree(info OsInfo) (map[string]interface{}, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	tree := map[string]interface{}{}
+	return tree, decoder.Decode(&tree)
+}
+
+func diffObjects(changes *Changes, path string, before map[string]interface{}, after map[string]interface{}) {
+	for _, k := range unionKeys(before, after) {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+		diffValues(changes, p, before[k], after[k])
+	}
+}
+
+func diffValues(changes *Changes, path string, before interface{}, after interface{}) {
+	switch {
+	case before == nil && after == nil:
+		return
+	case before == nil:
+		changes.add(path, ChangeAdded, nil, after)
+		return
+	case after == nil:
+		changes.add(path, ChangeRemoved, before, nil)
+		return
+	}
+
+	beforeObject, ok1 := before.(map[string]interface{})
+	afterObject, ok2 := after.(map[string]interface{})
+	if ok1 && ok2 {
+		diffObjects(changes, path, beforeObject, afterObject)
+		return
+	}
+
+	beforeList, ok1 := before.([]interface{})
+	afterList, ok2 := after.([]interface{})
+	if ok1 && ok2 {
+		beforeKeyed, ok1 := keyedList(beforeList)
+		afterKeyed, ok2 := keyedList(afterList)
+		if ok1 && ok2 {
+			diffKeyedLists(changes, path, beforeKeyed, afterKeyed)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		changes.add(path, ChangeModified, before, after)
+	}
+}
+
+func (c *Changes) add(path string, kind ChangeKind, before interface{}, after interface{}) {
+	top := path
+	if i := strings.IndexAny(top, ".["); i >= 0 {
+		top = top[:i]
+	}
+	volatile := volatileFields[top] || volatileFields[listElement.ReplaceAllString(path, "[*]")]
+	*c = append(*c, Change{Path: path, Kind: kind, Old: before, New: after, Volatile: volatile})
+}
+
+// keyedList returns the elements of a list of objects by the first of
+// listKeys that identifies every element. It returns false for lists of
+// other values and for lists whose elements can not be told apart.
+func keyedList(list []interface{}) (map[string]interface{}, bool) {
+	for _, fields := range listKeys {
+		keyed := map[string]interface{}{}
+		for _, elem := range list {
+			object, ok := elem.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			key := listKey(object, fields)
+			if key == "" {
+				break
+			}
+			if _, dup := keyed[key]; dup {
+				break
+			}
+			keyed[key] = object
+		}
+		if len(keyed) == len(list) {
+			return keyed, true
+		}
+	}
+	return nil, false
+}
+
+func listKey(object map[string]interface{}, fields []string) string {
+	values := []string{}
+	for _, f := range fields {
+		v, ok := object[f].(string)
+		if !ok || v == "" {
+			return ""
+		}
+		values = append(values, v)
+	}
+	return strings.Join(values, "/")
+}
+
+func diffKeyedLists(changes *Changes, path string, before map[string]interface{}, after map[string]interface{}) {
+	for _, k := range unionKeys(before, after) {
+		diffValues(changes, path+"["+k+"]", before[k], after[k])
+	}
+}
+
+// unionKeys returns the keys of a and b, sorted.
+func unionKeys(a map[string]interface{}, b map[string]interface{}) []string {
+	keys := []string{}
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
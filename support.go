@@ -0,0 +1,130 @@
+//
+// osinfo/support.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lifecycleJSON is the built-in lifecycle table: os-release ID -> VERSION_ID
+// -> end of security support. An empty VERSION_ID key matches any version
+// (rolling releases), and a missing "eol" means no end date is announced.
+//
+//go:embed lifecycle.json
+var lifecycleJSON []byte
+
+type lifecycleEntry struct {
+	EOL string `json:"eol"`
+	LTS bool   `json:"lts"`
+}
+
+var (
+	lifecycleMu    sync.Mutex
+	lifecycleTable map[string]map[string]lifecycleEntry
+)
+
+// SupportStatus is the vendor support status of the running release.
+// EOL is the zero time when no end date is known. Supported is always
+// encoded, so an unsupported release is told apart from an unknown one,
+// which has no SupportStatus at all.
+type SupportStatus struct {
+	EOL       time.Time `json:"eol,omitempty" yaml:"eol,omitempty"`
+	LTS       bool      `json:"lts,omitempty" yaml:"lts,omitempty"`
+	Supported bool      `json:"supported" yaml:"supported"`
+}
+
+// LoadLifecycle replaces the built-in lifecycle table with the JSON read
+// from r, which uses the same format as lifecycle.json.
+func LoadLifecycle(r io.Reader) error {
+	table := map[string]map[string]lifecycleEntry{}
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return err
+	}
+
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+	lifecycleTable = table
+	return nil
+}
+
+// GetSupportStatus looks up an os-release ID and VERSION_ID ("macos" and
+// the product version for macOS). VERSION_ID is shortened one component at
+// a time, so "9.3" matches "9". It returns nil for unknown releases.
+func GetSupportStatus(id string, versionID string) *SupportStatus {
+	entry, ok := lookupLifecycle(strings.ToLower(id), versionID)
+	if !ok {
+		return nil
+	}
+
+	status := SupportStatus{LTS: entry.LTS, Supported: true}
+	if eol, err := time.Parse("2006-01-02", entry.EOL); err == nil {
+		status.EOL = eol
+		status.Supported = time.Now().Before(eol)
+	}
+	return &status
+}
+
+func lookupLifecycle(id string, versionID string) (lifecycleEntry, bool) {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+	if lifecycleTable == nil {
+		if err := json.Unmarshal(lifecycleJSON, &lifecycleTable); err != nil {
+			return lifecycleEntry{}, false
+		}
+	}
+
+	versions, ok := lifecycleTable[id]
+	if !ok {
+		return lifecycleEntry{}, false
+	}
+	for ver := versionID; !emptyStr(ver); {
+		if entry, ok := versions[ver]; ok {
+			return entry, true
+		}
+		i := strings.LastIndex(ver, ".")
+		if i < 0 {
+			break
+		}
+		ver = ver[:i]
+	}
+	entry, ok := versions[""]
+	return entry, ok
+}
+
+// supportStatus returns the status of this machine. The os-release
+// SUPPORT_END field takes precedence over the lifecycle table.
+func supportStatus(os string, mac MacInfo) *SupportStatus {
+	if os == "Mac OS X" || os == "macOS" {
+		return GetSupportStatus("macos", mac.Ver)
+	}
+
+	release := osRelease()
+	status := GetSupportStatus(release["ID"], release["VERSION_ID"])
+	if end, err := time.Parse("2006-01-02", release["SUPPORT_END"]); err == nil {
+		if status == nil {
+			status = &SupportStatus{}
+		}
+		status.EOL = end
+		status.Supported = time.Now().Before(end)
+	}
+	return status
+}
@@ -0,0 +1,200 @@
+//
+// osinfo/template.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFiles holds the built-in templates, one <name>.tmpl per template.
+//
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// colorNames are the names accepted by the "color" template function.
+var colorNames = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3,
+	"blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+	"gray": 8, "brightred": 9, "brightgreen": 10, "brightyellow": 11,
+	"brightblue": 12, "brightmagenta": 13, "brightcyan": 14, "brightwhite": 15,
+}
+
+// Templates returns the names of the built-in templates.
+func Templates() []string {
+	names := []string{}
+	entries, err := templateFiles.ReadDir("templates")
+	if err != nil {
+		return names
+	}
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".tmpl"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render executes tmpl with info as its data. tmpl is the name of a
+// built-in template (see Templates) or text/template source. ANSI colors
+// are left out when the NO_COLOR environment variable is set.
+//
+// Besides the text/template built-ins, templates can call:
+//
+//	bytes N            1536 -> "1.5 KiB"
+//	duration D         time.Duration or seconds -> "2 days, 3 hours, 4 minutes"
+//	color NAME S       S in a named color ("red", "brightblue") or 256 color number
+//	pad N S            S padded with spaces to N columns
+//	padleft N S        S right-aligned in N columns
+//	repeat S N         S repeated N times
+//	width S            columns S occupies on a terminal
+//	join SEP LIST      strings.Join
+//	hostname           DisplayHostname of the OsInfo
+//	username           GetUsername
+func Render(info OsInfo, tmpl string) (string, error) {
+	return RenderWithColor(info, tmpl, os.Getenv("NO_COLOR") == "")
+}
+
+// RenderWithColor is Render with ANSI colors turned on or off explicitly.
+func RenderWithColor(info OsInfo, tmpl string, color bool) (string, error) {
+	name := "osinfo"
+	if builtin, err := templateFiles.ReadFile(path.Join("templates", tmpl+".tmpl")); err == nil {
+		name, tmpl = tmpl, string(builtin)
+	}
+
+	t, err := template.New(name).Funcs(templateFuncs(info, color)).Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, info); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func templateFuncs(info OsInfo, color bool) template.FuncMap {
+	return template.FuncMap{
+		"bytes":    formatBytes,
+		"duration": formatDuration,
+		"color": func(name interface{}, s string) (string, error) {
+			if !color {
+				return s, nil
+			}
+			n, err := colorNumber(name)
+			if err != nil {
+				return "", err
+			}
+			return ansiColor(n) + s + colorReset, nil
+		},
+		"pad": func(n int, s string) string {
+			if w := DisplayWidth(s); w < n {
+				return s + strings.Repeat(" ", n-w)
+			}
+			return s
+		},
+		"padleft": func(n int, s string) string {
+			if w := DisplayWidth(s); w < n {
+				return strings.Repeat(" ", n-w) + s
+			}
+			return s
+		},
+		"repeat": func(s string, n int) string {
+			if n < 0 {
+				return ""
+			}
+			return strings.Repeat(s, n)
+		},
+		"width":    DisplayWidth,
+		"join":     func(sep string, list []string) string { return strings.Join(list, sep) },
+		"hostname": func() string { return DisplayHostname(info) },
+		"username": GetUsername,
+	}
+}
+
+func colorNumber(name interface{}) (int, error) {
+	switch v := name.(type) {
+	case int:
+		return v, nil
+	case string:
+		if n, ok := colorNames[strings.ToLower(v)]; ok {
+			return n, nil
+		}
+		if n, err := strconv.Atoi(v); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown color %v", name)
+}
+
+// formatBytes formats a byte count with binary (IEC) units.
+func formatBytes(n interface{}) (string, error) {
+	var size float64
+	switch v := n.(type) {
+	case int:
+		size = float64(v)
+	case int64:
+		size = float64(v)
+	case uint64:
+		size = float64(v)
+	case float64:
+		size = v
+	default:
+		return "", fmt.Errorf("bytes: unsupported type %T", n)
+	}
+
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	i := 0
+	for ; size >= 1024 && i < len(units)-1; i++ {
+		size /= 1024
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", size, units[i]), nil
+	}
+	return strconv.FormatFloat(size, 'f', 1, 64) + " " + units[i], nil
+}
+
+// formatDuration formats a duration the way Uptime is formatted. Plain
+// numbers are seconds.
+func formatDuration(d interface{}) (string, error) {
+	var sec int64
+	switch v := d.(type) {
+	case time.Duration:
+		sec = int64(v / time.Second)
+	case int:
+		sec = int64(v)
+	case int64:
+		sec = v
+	case float64:
+		sec = int64(v)
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return "", err
+		}
+		sec = int64(parsed / time.Second)
+	default:
+		return "", fmt.Errorf("duration: unsupported type %T", d)
+	}
+	return formatUptime(sec), nil
+}
@@ -17,14 +17,18 @@
 package osinfo
 
 import (
-	"fmt"
-	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
-func getUptime(os string) string {
+// formatUptime formats seconds as "2 days, 3 hours, 4 minutes".
+func formatUptime(sec int64) string {
+	return strings.TrimSpace(secToUptime(strconv.FormatInt(sec, 10)))
+}
+
+func uptimeSeconds(os string) int64 {
 	sec := "0"
 	switch os {
 	case "Linux", "Windows", "MINIX":
@@ -38,7 +42,24 @@ func getUptime(os string) string {
 	case "Haiku":
 		sec = uptimeSecForHaiku()
 	}
-	return secToUptime(strings.ReplaceAll(sec, "\n", ""))
+	n, _ := strconv.ParseInt(strings.TrimSpace(sec), 10, 64)
+	return n
+}
+
+// bootTime returns the Unix time the system booted: btime of /proc/stat,
+// or the current time minus the uptime.
+func bootTime(uptime int64) int64 {
+	for _, line := range strings.Split(readFile("/proc/stat"), "\n") {
+		if strings.HasPrefix(line, "btime ") {
+			if btime, err := strconv.ParseInt(strings.TrimSpace(line[len("btime "):]), 10, 64); err == nil {
+				return btime
+			}
+		}
+	}
+	if uptime <= 0 {
+		return 0
+	}
+	return time.Now().Unix() - uptime
 }
 
 func secToUptime(sec string) string {
@@ -98,11 +119,11 @@ func uptimeSecForLinuxWinMinix() string {
 		sec = readFile("/proc/uptime")
 		sec = removeStringByRegexp(sec, "\\..*")
 	} else {
-		boot, err := exec.Command("date", "-d\"$(uptime -s)\"", "+%s").Output()
+		boot, err := runCmd("date", "-d\"$(uptime -s)\"", "+%s")
 		if err != nil {
 			return sec
 		}
-		now, err := exec.Command("date", "+%s").Output()
+		now, err := runCmd("date", "+%s")
 		if err != nil {
 			return sec
 		}
@@ -113,12 +134,12 @@ func uptimeSecForLinuxWinMinix() string {
 
 func uptimeSecForAppleBsdFreemint() string {
 	sec := "0"
-	boot, err := exec.Command("sysctl", "-n", "kern.boottime").Output()
+	boot, err := runCmd("sysctl", "-n", "kern.boottime")
 	if err != nil {
 		return sec
 	}
 
-	now, err := exec.Command("date", "+%s").Output()
+	now, err := runCmd("date", "+%s")
 	if err != nil {
 		return sec
 	}
@@ -129,7 +150,7 @@ func uptimeSecForAppleBsdFreemint() string {
 }
 
 func uptimeSecForSolaris() string {
-	time, err := exec.Command("kstat", "-p", "unix:0:system_misc:snaptime").Output()
+	time, err := runCmd("kstat", "-p", "unix:0:system_misc:snaptime")
 	if err != nil {
 		return "0"
 	}
@@ -139,7 +160,7 @@ func uptimeSecForSolaris() string {
 
 func uptimeSecForAixIrix() string {
 	// time = 2-04:55:07  <-- 2day, 4hours, 55min, 7sec
-	time, err := exec.Command("env", "LC_ALL=POSIX", "ps", "-o", "etime=", "-p", "1").Output()
+	time, err := runCmd("env", "LC_ALL=POSIX", "ps", "-o", "etime=", "-p", "1")
 	if err != nil {
 		return "0"
 	}
@@ -152,7 +173,6 @@ func uptimeSecForAixIrix() string {
 		timeStr = removeStringByRegexp(timeStr, ".*-")
 	}
 
-	fmt.Println(timeStr)
 	r := regexp.MustCompile(`[0-9][0-9]:[0-9][0-9]:[0-9][0-9]`)
 	if r.MatchString(timeStr) {
 		hour = timeStr[0:2]
@@ -166,7 +186,7 @@ func uptimeSecForAixIrix() string {
 }
 
 func uptimeSecForHaiku() string {
-	time, err := exec.Command("system_time").Output()
+	time, err := runCmd("system_time")
 	if err != nil {
 		return "0"
 	}
@@ -17,10 +17,14 @@
 package osinfo
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 const (
@@ -39,6 +43,11 @@ func isFile(path string) bool {
 	return (err == nil) && (!stat.IsDir())
 }
 
+func isSymlink(path string) bool {
+	stat, err := os.Lstat(path)
+	return (err == nil) && (stat.Mode()&os.ModeSymlink != 0)
+}
+
 func isDir(path string) bool {
 	stat, err := os.Stat(path)
 	return (err == nil) && (stat.IsDir())
@@ -65,12 +74,77 @@ func readFile(filePath string) string {
 	return string(bytes)
 }
 
+// errProbeDisabled is returned by runCmd for commands listed in
+// Options.DisabledProbes.
+var errProbeDisabled = errors.New("probe is disabled")
+
 func existCmd(cmd string) bool {
-	_, err := exec.LookPath(cmd)
-	return err == nil
+	if probeDisabled(cmd) {
+		return false
+	}
+	entry := memoized("\x00lookpath\x00" + cmd)
+	if entry == nil {
+		_, err := exec.LookPath(cmd)
+		return err == nil
+	}
+	entry.once.Do(func() {
+		_, entry.err = exec.LookPath(cmd)
+	})
+	return entry.err == nil
+}
+
+// runCmd runs a command and returns its standard output. The command is
+// not spawned when it is disabled, and is killed after Options.Timeout.
+// During a collection, a command runs once and its output is reused.
+func runCmd(name string, args ...string) ([]byte, error) {
+	if probeDisabled(name) {
+		return nil, errProbeDisabled
+	}
+	entry := memoized(name + "\x00" + strings.Join(args, "\x00"))
+	if entry == nil {
+		return execCmd(name, args...)
+	}
+	entry.once.Do(func() {
+		entry.out, entry.err = execCmd(name, args...)
+	})
+	return entry.out, entry.err
+}
+
+func execCmd(name string, args ...string) ([]byte, error) {
+	ctx := context.Background()
+	if timeout := probeTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+func probeDisabled(cmd string) bool {
+	probes.RLock()
+	defer probes.RUnlock()
+	return probes.disabled[filepath.Base(cmd)]
 }
 
 func removeStringByRegexp(str string, pattern string) string {
 	rep := regexp.MustCompile(pattern)
 	return rep.ReplaceAllString(str, "")
 }
+
+// parseKeyValue parses shell-style KEY=VALUE files such as os-release.
+func parseKeyValue(contents string) map[string]string {
+	kv := map[string]string{}
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if emptyStr(line) || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		elem := strings.SplitN(line, "=", 2)
+		if len(elem) != 2 {
+			continue
+		}
+		kv[strings.TrimSpace(elem[0])] = strings.Trim(strings.TrimSpace(elem[1]), "\"'")
+	}
+	return kv
+}
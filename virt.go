@@ -0,0 +1,179 @@
+//
+// osinfo/virt.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// containerIDPattern matches the 64 hex digit IDs of Docker, containerd,
+// CRI-O and podman containers.
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// Container is the container the system runs in. Runtime is the identifier
+// of systemd-detect-virt ("docker", "podman", "lxc", ...). ID is the
+// container ID when the runtime exposes it in the cgroup or mount table.
+type Container struct {
+	Runtime string `json:"runtime" yaml:"runtime"`
+	ID      string `json:"id,omitempty" yaml:"id,omitempty"`
+}
+
+// dmiHypervisors maps DMI vendor and product strings to the identifiers
+// of systemd-detect-virt. Order matters: KVM guests report "QEMU" too.
+var dmiHypervisors = []struct {
+	match string
+	virt  string
+}{
+	{"KVM", "kvm"},
+	{"Amazon EC2", "amazon"},
+	{"Google Compute Engine", "google"},
+	{"QEMU", "qemu"},
+	{"VMware", "vmware"},
+	{"VMW", "vmware"},
+	{"innotek GmbH", "oracle"},
+	{"VirtualBox", "oracle"},
+	{"Oracle Corporation", "oracle"},
+	{"Xen", "xen"},
+	{"Bochs", "bochs"},
+	{"Parallels", "parallels"},
+	{"BHYVE", "bhyve"},
+	{"Apple Virtualization", "apple"},
+	{"Hyper-V", "microsoft"},
+}
+
+// GetVirtualization returns what the system runs in, using the identifiers
+// of systemd-detect-virt: the container type ("docker", "podman", "lxc",
+// "systemd-nspawn", "wsl", ...) if any, otherwise the hypervisor ("kvm",
+// "vmware", "microsoft", ...). It returns "" on bare metal.
+func GetVirtualization() string {
+	if runtime := containerRuntime(); !emptyStr(runtime) {
+		return runtime
+	}
+	return hypervisor()
+}
+
+// GetContainer returns the container, or nil outside of a container.
+func GetContainer() *Container {
+	runtime := containerRuntime()
+	if emptyStr(runtime) {
+		return nil
+	}
+	return &Container{
+		Runtime: runtime,
+		ID:      containerID(readFile("/proc/self/cgroup"), readFile("/proc/self/mountinfo")),
+	}
+}
+
+func containerRuntime() string {
+	if c := containerFromEnviron(readFile("/proc/1/environ")); !emptyStr(c) {
+		return c
+	}
+	if c := strings.TrimSpace(readFile("/run/systemd/container")); !emptyStr(c) {
+		return c
+	}
+	if isFile("/run/.containerenv") {
+		return "podman"
+	}
+	if isFile("/.dockerenv") {
+		return "docker"
+	}
+	if strings.Contains(strings.ToLower(readFile("/proc/sys/kernel/osrelease")), "microsoft") {
+		return "wsl"
+	}
+	return ""
+}
+
+// containerFromEnviron returns the "container" variable of a NUL separated
+// environment, which systemd-nspawn, LXC and podman set for PID 1.
+func containerFromEnviron(environ string) string {
+	for _, kv := range strings.Split(environ, "\x00") {
+		if strings.HasPrefix(kv, "container=") {
+			c := strings.TrimPrefix(kv, "container=")
+			if c == "oci" {
+				return "docker"
+			}
+			return c
+		}
+	}
+	return ""
+}
+
+// containerID finds the container ID in /proc/self/cgroup, which has it with
+// cgroup v1 ("12:pids:/docker/<id>"), or else in /proc/self/mountinfo, where
+// the runtime bind mounts /etc/hostname from its per-container directory.
+func containerID(cgroup string, mountinfo string) string {
+	if id := containerIDPattern.FindString(cgroup); !emptyStr(id) {
+		return id
+	}
+	for _, line := range strings.Split(mountinfo, "\n") {
+		if strings.Contains(line, "/containers/") {
+			if id := containerIDPattern.FindString(line); !emptyStr(id) {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+func hypervisor() string {
+	if virt := dmiVirtualization(dmiValue("sys_vendor"), dmiValue("product_name"),
+		dmiValue("bios_vendor"), dmiValue("board_vendor")); !emptyStr(virt) {
+		return virt
+	}
+	if strings.Contains(readFile(filepath.Join(deviceTreeDir, "hypervisor", "compatible")), "linux,kvm") {
+		return "kvm"
+	}
+	if strings.TrimSpace(readFile("/sys/hypervisor/type")) == "xen" {
+		return "xen"
+	}
+	if cpuHasHypervisorFlag(readFile("/proc/cpuinfo")) {
+		return "vm-other"
+	}
+	return ""
+}
+
+// dmiVirtualization matches the DMI strings against known hypervisors.
+// Hyper-V reports "Microsoft Corporation" and "Virtual Machine".
+func dmiVirtualization(values ...string) string {
+	joined := strings.Join(values, "\n")
+	for _, h := range dmiHypervisors {
+		if strings.Contains(joined, h.match) {
+			return h.virt
+		}
+	}
+	if strings.Contains(joined, "Microsoft Corporation") && strings.Contains(joined, "Virtual Machine") {
+		return "microsoft"
+	}
+	return ""
+}
+
+func cpuHasHypervisorFlag(cpuinfo string) bool {
+	for _, line := range strings.Split(cpuinfo, "\n") {
+		if !strings.HasPrefix(line, "flags") {
+			continue
+		}
+		for _, flag := range strings.Fields(line) {
+			if flag == "hypervisor" {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
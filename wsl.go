@@ -0,0 +1,119 @@
+//
+// osinfo/wsl.go
+//
+// Copyright 2021 Naohiro CHIKAMATSU
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package osinfo
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// WSL is the Windows Subsystem for Linux environment osinfo runs in.
+type WSL struct {
+	Version      int    `json:"version,omitempty" yaml:"version,omitempty"`
+	Distro       string `json:"distro,omitempty" yaml:"distro,omitempty"`
+	WindowsBuild string `json:"windows_build,omitempty" yaml:"windows_build,omitempty"`
+	Interop      bool   `json:"interop,omitempty" yaml:"interop,omitempty"`
+}
+
+// GetWSL returns the WSL information, or nil when not running under WSL.
+func GetWSL(kernelVer string) *WSL {
+	procVer := readFile("/proc/version")
+	if !onWindows(kernelVer, procVer) {
+		return nil
+	}
+
+	wsl := WSL{
+		Version: wslVersion(kernelVer, procVer),
+		Distro:  os.Getenv("WSL_DISTRO_NAME"),
+		Interop: wslInterop(),
+	}
+	if wsl.Version == 1 {
+		wsl.WindowsBuild = wsl1WindowsBuild(kernelVer)
+	}
+	if emptyStr(wsl.WindowsBuild) && wsl.Interop {
+		wsl.WindowsBuild = windowsBuild()
+	}
+	return &wsl
+}
+
+// onWindows reports whether the Linux kernel is a WSL kernel. WSL1 says
+// "Microsoft", the WSL2 kernel says "microsoft-standard".
+func onWindows(kernelVer string, procVer string) bool {
+	return strings.Contains(strings.ToLower(kernelVer), "microsoft") ||
+		strings.Contains(strings.ToLower(procVer), "microsoft") ||
+		isFile("/proc/sys/fs/binfmt_misc/WSLInterop") ||
+		isFile("/proc/sys/fs/binfmt_misc/WSLInterop-late") ||
+		isDir("/run/WSL")
+}
+
+func wslVersion(kernelVer string, procVer string) int {
+	if strings.Contains(kernelVer, "Microsoft") || strings.Contains(procVer, "Microsoft") {
+		if !strings.Contains(strings.ToLower(procVer), "microsoft-standard") {
+			return 1
+		}
+	}
+	return 2
+}
+
+func wslInterop() bool {
+	for _, v := range []string{
+		"/proc/sys/fs/binfmt_misc/WSLInterop",
+		"/proc/sys/fs/binfmt_misc/WSLInterop-late"} {
+		if strings.HasPrefix(readFile(v), "enabled") {
+			return true
+		}
+	}
+	return false
+}
+
+// wsl1WindowsBuild returns the build WSL1 puts in the kernel release,
+// e.g. "4.4.0-19041-Microsoft" -> "19041".
+func wsl1WindowsBuild(kernelVer string) string {
+	m := regexp.MustCompile(`-(\d+)-Microsoft`).FindStringSubmatch(kernelVer)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// windowsBuild asks the host through interop, e.g.
+// "Microsoft Windows [Version 10.0.22631.4317]" -> "10.0.22631.4317".
+func windowsBuild() string {
+	cmd := "cmd.exe"
+	if !existCmd(cmd) {
+		cmd = "/mnt/c/Windows/System32/cmd.exe"
+		if !isFile(cmd) {
+			return ""
+		}
+	}
+
+	out, err := runCmd(cmd, "/c", "ver")
+	if err != nil {
+		return ""
+	}
+	return parseWindowsVer(string(out))
+}
+
+// parseWindowsVer returns the version in the output of "ver".
+func parseWindowsVer(out string) string {
+	m := regexp.MustCompile(`Version ([\d.]+)`).FindStringSubmatch(out)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}